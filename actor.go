@@ -18,11 +18,7 @@ func AddActor(layer uint, actor, state interface{}) {
 		return
 	}
 	_actors[cur] = append(_actors[cur], actor)
-	if l, ok := _actorLayers[cur][layer]; ok {
-		l = append(l, actor)
-	} else {
-		_actorLayers[cur][layer] = []interface{}{actor}
-	}
+	_actorLayers[cur][layer] = append(_actorLayers[cur][layer], actor)
 	if layer > _highestLayer {
 		_highestLayer = layer
 	}
@@ -62,14 +58,14 @@ func DestroyActor(actor interface{}) {
 	if cur == nil {
 		return
 	}
-	actors := _state.Actors()
+	actors := _actors[cur]
 	for i, a := range actors {
 		if a == actor {
-			actors = append(actors[:i], actors[i+1:]...)
+			_actors[cur] = append(actors[:i], actors[i+1:]...)
 			break
 		}
 	}
-	for i := uint(0); i < _highestLayer; i++ {
+	for i := uint(0); i <= _highestLayer; i++ {
 		layer, ok := _actorLayers[cur][i]
 		if !ok {
 			continue
@@ -81,6 +77,7 @@ func DestroyActor(actor interface{}) {
 		}
 		_actorLayers[cur][i] = layer
 	}
+	delete(_actorLastUpdate, actor)
 	if actor, ok := actor.(Cleanupable); ok {
 		actor.Cleanup()
 	}
@@ -0,0 +1,3 @@
+// Package allegorytest provides helpers for testing games built with
+// allegory.
+package allegorytest
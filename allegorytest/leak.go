@@ -0,0 +1,60 @@
+package allegorytest
+
+import (
+	"github.com/dradtke/allegory"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// DefaultLeakTimeout is how long After() waits for processes and their
+// goroutines to finish if LeakChecker.Timeout isn't set.
+const DefaultLeakTimeout = 5 * time.Second
+
+// LeakChecker verifies that a test doesn't leave any goroutines behind,
+// which usually means a process was never closed:
+//
+//	func TestEnemy(t *testing.T) {
+//		var leaks allegorytest.LeakChecker
+//		leaks.Before(t)
+//		defer leaks.After(t)
+//		...
+//	}
+//
+// It compares runtime.NumGoroutine() before and after the test.
+type LeakChecker struct {
+	baseline int
+
+	// Timeout is how long After() waits for things to wind down.
+	Timeout time.Duration
+}
+
+// Before() records the number of goroutines running before the test.
+func (c *LeakChecker) Before(t testing.TB) {
+	t.Helper()
+	c.baseline = runtime.NumGoroutine()
+}
+
+// After() waits for every process to finish, then fails the test if
+// more goroutines are running than when Before() was called.
+func (c *LeakChecker) After(t testing.TB) {
+	t.Helper()
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultLeakTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	if !allegory.WaitForAllProcesses(timeout) {
+		t.Errorf("processes were still running after %s", timeout)
+		return
+	}
+	// goroutines take a moment to exit after their process is done
+	for runtime.NumGoroutine() > c.baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > c.baseline {
+		buf := make([]byte, 1<<16)
+		buf = buf[:runtime.Stack(buf, true)]
+		t.Errorf("%d goroutine(s) leaked:\n%s", n-c.baseline, buf)
+	}
+}
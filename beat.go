@@ -0,0 +1,96 @@
+package allegory
+
+import (
+	"errors"
+	"github.com/dradtke/allegory/bus"
+	"sort"
+	"time"
+)
+
+// BeatTimeline is a list of events tied to beats of music rather than
+// to frames, for use in rhythm games.
+type BeatTimeline struct {
+	bpm    float64
+	events []beatEvent
+
+	// Offset delays the timeline to account for audio latency, so that
+	// events fire when the beat is actually heard.
+	Offset time.Duration
+}
+
+type beatEvent struct {
+	beat      float64
+	eventType bus.EventId
+	params    []interface{}
+}
+
+// SetBPM() sets the tempo of the timeline in beats per minute.
+func (t *BeatTimeline) SetBPM(bpm float64) {
+	t.bpm = bpm
+}
+
+// AddEvent() schedules an event to be signaled on the bus once the
+// timeline reaches the given beat. Beats are counted from 0, and
+// fractional beats are allowed.
+func (t *BeatTimeline) AddEvent(beat float64, eventType bus.EventId, params ...interface{}) {
+	t.events = append(t.events, beatEvent{beat, eventType, params})
+	sort.SliceStable(t.events, func(i, j int) bool {
+		return t.events[i].beat < t.events[j].beat
+	})
+}
+
+// Start() kicks off a BeatTimelineProcess for this timeline and returns it.
+func (t *BeatTimeline) Start() *BeatTimelineProcess {
+	p := &BeatTimelineProcess{Timeline: t}
+	RunProcess(p)
+	return p
+}
+
+/* -- BeatTimelineProcess -- */
+
+// BeatTimelineProcess plays back a BeatTimeline. Each tick it checks the
+// time elapsed since it started, signals any events whose beat
+// has been reached, and signals bus.BeatEvent at the start of every beat.
+// Time is measured from the wall clock instead of by counting ticks so
+// that it stays in sync with the music even if frames are dropped.
+type BeatTimelineProcess struct {
+	start    time.Time
+	next     int // index of the next event to fire
+	lastBeat int // the last beat number signaled
+
+	Timeline *BeatTimeline
+}
+
+func (p *BeatTimelineProcess) init() error {
+	if p.Timeline == nil || p.Timeline.bpm <= 0 {
+		return errors.New("beat timeline must have a positive bpm")
+	}
+	p.start = time.Now()
+	p.next = 0
+	p.lastBeat = -1
+	return nil
+}
+
+func (p *BeatTimelineProcess) tick() (bool, error) {
+	beat := p.CurrentBeat()
+	if beat < 0 {
+		return true, nil
+	}
+	for b := p.lastBeat + 1; b <= int(beat); b++ {
+		bus.Signal(bus.BeatEvent, b)
+		p.lastBeat = b
+	}
+	for events := p.Timeline.events; p.next < len(events) && events[p.next].beat <= beat; p.next++ {
+		e := events[p.next]
+		bus.Signal(e.eventType, e.params...)
+	}
+	return true, nil
+}
+
+// CurrentBeat() returns how many beats have passed since the timeline
+// started, including the fractional part. It's negative while
+// waiting out the offset.
+func (p *BeatTimelineProcess) CurrentBeat() float64 {
+	elapsed := time.Since(p.start) - p.Timeline.Offset
+	return elapsed.Minutes() * p.Timeline.bpm
+}
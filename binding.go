@@ -0,0 +1,236 @@
+package allegory
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Binding keeps a property of a view in sync with a field of a model.
+// Once per frame, after every actor has been updated, the field is
+// compared to its value the last time around, and if it has changed, the
+// view's property is set to the new value.
+type Binding struct {
+	model    reflect.Value
+	path     []string
+	view     interface{}
+	set      func(reflect.Value) bool
+	last     interface{} // the value most recently given to the view
+	primed   bool        // has the view been given a value yet?
+	snapshot bool        // copy slices, so that changes to their elements are noticed?
+}
+
+// Bind() binds a model's field to a view's property, so that changes to
+// the field automatically show up in the view instead of having to be
+// copied over by hand, e.g.
+//
+//    Bind(player, "Stats.Health", healthLabel, "Text")
+//
+// keeps healthLabel's text up to date with player.Stats.Health. The field
+// path is a dot-separated list of exported fields, and model should be a
+// pointer so that changes to it can be seen. The property is set with a
+// SetProperty() method on the view if it has one, or by assigning to an
+// exported field named property otherwise. Values are converted to the
+// property's type if needed, so numbers can be bound to string properties.
+//
+// The view is given the field's current value on the next frame.
+func Bind(model interface{}, fieldPath string, view interface{}, property string) (*Binding, error) {
+	return bind(model, fieldPath, view, property, false)
+}
+
+// BindSlice() is like Bind(), but for fields that are slices or arrays.
+// Unlike Bind(), changes to the elements are noticed even if the slice
+// itself stays the same. The property can be of any slice type that the
+// elements can be converted to.
+func BindSlice(model interface{}, fieldPath string, view interface{}, property string) (*Binding, error) {
+	return bind(model, fieldPath, view, property, true)
+}
+
+func bind(model interface{}, fieldPath string, view interface{}, property string, snapshot bool) (*Binding, error) {
+	b := &Binding{
+		model:    reflect.ValueOf(model),
+		path:     strings.Split(fieldPath, "."),
+		view:     view,
+		snapshot: snapshot,
+	}
+	field, err := b.field()
+	if err != nil {
+		return nil, err
+	}
+	if kind := field.Kind(); snapshot && kind != reflect.Slice && kind != reflect.Array {
+		return nil, fmt.Errorf("%s is a %s, not a slice", fieldPath, field.Type())
+	}
+	if b.set, err = setter(view, property, field.Type()); err != nil {
+		return nil, err
+	}
+
+	_bindingsMutex.Lock()
+	defer _bindingsMutex.Unlock()
+	_bindings = append(_bindings, b)
+	return b, nil
+}
+
+// Unbind() removes the binding. The view keeps whatever value it was
+// last given.
+func (b *Binding) Unbind() {
+	_bindingsMutex.Lock()
+	defer _bindingsMutex.Unlock()
+	for i, other := range _bindings {
+		if other == b {
+			copy(_bindings[i:], _bindings[i+1:])
+			_bindings[len(_bindings)-1] = nil
+			_bindings = _bindings[:len(_bindings)-1]
+			return
+		}
+	}
+}
+
+// field() follows the binding's path to the field it's bound to.
+func (b *Binding) field() (reflect.Value, error) {
+	v := b.model
+	for _, name := range b.path {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return reflect.Value{}, fmt.Errorf("nil value before field %s", name)
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("can't get field %s of a %s", name, v.Type())
+		}
+		f, ok := v.Type().FieldByName(name)
+		if !ok || f.PkgPath != "" {
+			return reflect.Value{}, fmt.Errorf("%s has no exported field %s", v.Type(), name)
+		}
+		v = v.FieldByIndex(f.Index)
+	}
+	return v, nil
+}
+
+// update() passes the field's value to the view if it has changed.
+func (b *Binding) update() {
+	field, err := b.field()
+	if err != nil {
+		// the path may become valid again later, e.g. once a pointer is set
+		return
+	}
+	current := field.Interface()
+	if b.snapshot {
+		copied := reflect.MakeSlice(reflect.SliceOf(field.Type().Elem()), field.Len(), field.Len())
+		reflect.Copy(copied, field)
+		current = copied.Interface()
+	}
+	if b.primed && reflect.DeepEqual(current, b.last) {
+		return
+	}
+	if b.set(reflect.ValueOf(current)) {
+		b.last, b.primed = current, true
+	}
+}
+
+// updateBindings() updates every binding. It's called once per frame.
+func updateBindings() {
+	_bindingsMutex.Lock()
+	bindings := append([]*Binding(nil), _bindings...)
+	_bindingsMutex.Unlock()
+	for _, b := range bindings {
+		b.update()
+	}
+}
+
+// setter() returns a function that sets a view's property, or an error if
+// it doesn't have a property by that name that values of type from can be
+// converted to.
+func setter(view interface{}, property string, from reflect.Type) (func(reflect.Value) bool, error) {
+	v := reflect.ValueOf(view)
+	if !v.IsValid() {
+		return nil, errors.New("can't bind to a nil view")
+	}
+	if method := v.MethodByName("Set" + property); method.IsValid() {
+		t := method.Type()
+		if t.NumIn() != 1 {
+			return nil, fmt.Errorf("%T.Set%s() should take one parameter", view, property)
+		}
+		to := t.In(0)
+		if !canConvert(from, to) {
+			return nil, fmt.Errorf("can't convert %s to %s for %T.Set%s()", from, to, view, property)
+		}
+		return func(value reflect.Value) bool {
+			converted, ok := convertValue(value, to)
+			if ok {
+				method.Call([]reflect.Value{converted})
+			}
+			return ok
+		}, nil
+	}
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Struct {
+		if f, ok := v.Type().FieldByName(property); ok && f.PkgPath == "" && v.CanAddr() {
+			if !canConvert(from, f.Type) {
+				return nil, fmt.Errorf("can't convert %s to %s for %T.%s", from, f.Type, view, property)
+			}
+			field := v.FieldByIndex(f.Index)
+			return func(value reflect.Value) bool {
+				converted, ok := convertValue(value, f.Type)
+				if ok {
+					field.Set(converted)
+				}
+				return ok
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("%T has no property %s", view, property)
+}
+
+// canConvert() returns true if convertValue() can convert values of type
+// from to type to.
+func canConvert(from, to reflect.Type) bool {
+	switch {
+	case from.AssignableTo(to), to.Kind() == reflect.String:
+		return true
+	case isNumber(from) && isNumber(to):
+		return true
+	case (from.Kind() == reflect.Slice || from.Kind() == reflect.Array) && to.Kind() == reflect.Slice:
+		return canConvert(from.Elem(), to.Elem())
+	}
+	return false
+}
+
+// convertValue() converts a value to type to. Anything can be converted to
+// a string, numbers can be converted to other kinds of numbers, and slices
+// are converted element by element.
+func convertValue(v reflect.Value, to reflect.Type) (reflect.Value, bool) {
+	from := v.Type()
+	switch {
+	case from.AssignableTo(to):
+		return v, true
+	case isNumber(from) && isNumber(to):
+		return v.Convert(to), true
+	case to.Kind() == reflect.String:
+		return reflect.ValueOf(fmt.Sprint(v.Interface())).Convert(to), true
+	case (from.Kind() == reflect.Slice || from.Kind() == reflect.Array) && to.Kind() == reflect.Slice:
+		converted := reflect.MakeSlice(to, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem, ok := convertValue(v.Index(i), to.Elem())
+			if !ok {
+				return reflect.Value{}, false
+			}
+			converted.Index(i).Set(elem)
+		}
+		return converted, true
+	}
+	return reflect.Value{}, false
+}
+
+func isNumber(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package bus
+
+import (
+	"sync"
+)
+
+// asyncPool runs async listeners on a pool of worker goroutines.
+type asyncPool struct {
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	queue   []func()
+	size    int // the number of workers wanted
+	workers int // the number of workers running
+	pending int // the number of calls queued or running
+}
+
+var _async = newAsyncPool(1)
+
+func newAsyncPool(size int) *asyncPool {
+	p := &asyncPool{size: size}
+	p.cond = sync.NewCond(&p.mutex)
+	return p
+}
+
+// AddAsyncListener() registers a handler like AddListener(), but instead of
+// being called in the middle of Signal(), the call is queued up and made on
+// a worker goroutine, so slow handlers don't hold up the caller. Panics in
+// async handlers are recovered and logged. Calls are made in the order
+// they're queued, though with more than one worker they may overlap; see
+// SetWorkerPoolSize().
+func (b *Bus) AddAsyncListener(eventType EventId, f interface{}, curry ...interface{}) ListenerID {
+	return b.addListener(eventType, f, 0, true, curry)
+}
+
+// AddAsyncListener() registers an async handler on the default bus; see
+// (*Bus).AddAsyncListener().
+func AddAsyncListener(eventType EventId, f interface{}, curry ...interface{}) ListenerID {
+	return _default.AddAsyncListener(eventType, f, curry...)
+}
+
+// SetWorkerPoolSize() sets how many async handlers can run at once. The
+// default is 1, which means they run one at a time.
+func SetWorkerPoolSize(n int) {
+	if n < 1 {
+		n = 1
+	}
+	_async.mutex.Lock()
+	defer _async.mutex.Unlock()
+	_async.size = n
+	for _async.workers < _async.size && _async.workers < len(_async.queue) {
+		_async.workers++
+		go _async.work()
+	}
+	// wake up idle workers so any extras can exit
+	_async.cond.Broadcast()
+}
+
+// DrainAsync() blocks until every async handler call queued so far, and
+// any queued while waiting, has finished.
+func DrainAsync() {
+	_async.mutex.Lock()
+	defer _async.mutex.Unlock()
+	for _async.pending > 0 {
+		_async.cond.Wait()
+	}
+}
+
+// callAsync() queues a call to an async listener.
+func callAsync(eventType EventId, call func()) {
+	_async.enqueue(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				printErrorf("async callback for event type %d panicked: %v\n", eventType, r)
+			}
+		}()
+		call()
+	})
+}
+
+func (p *asyncPool) enqueue(job func()) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.queue = append(p.queue, job)
+	p.pending++
+	if p.workers < p.size {
+		p.workers++
+		go p.work()
+	}
+	// broadcast rather than signal, in case the worker woken up is one
+	// that's about to exit after the pool shrank
+	p.cond.Broadcast()
+}
+
+func (p *asyncPool) work() {
+	p.mutex.Lock()
+	for {
+		for len(p.queue) == 0 && p.workers <= p.size {
+			p.cond.Wait()
+		}
+		if p.workers > p.size {
+			p.workers--
+			p.mutex.Unlock()
+			return
+		}
+		job := p.queue[0]
+		p.queue[0] = nil
+		p.queue = p.queue[1:]
+		p.mutex.Unlock()
+
+		job()
+
+		p.mutex.Lock()
+		p.pending--
+		// wakes DrainAsync() as well as idle workers
+		p.cond.Broadcast()
+	}
+}
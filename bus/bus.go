@@ -10,27 +10,68 @@
 // an id for it and using it to register handlers, then signal
 // it whenever it happens. Handlers are run synchronously, so if
 // they need to perform some long computation, then they should kick
-// off their own goroutine.
+// off their own goroutine or be registered with AddAsyncListener().
+//
+// The package-level functions work on a default bus. Subsystems that
+// want their own events kept separate can create a bus with New(). Buses
+// can be used from any goroutine.
 package bus
 
 import (
-	"container/list"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
 	"runtime"
+	"sort"
+	"sync"
 	"sync/atomic"
 )
 
 type EventId uint32
 
 var (
-	_bus            = make(map[EventId]*list.List)
-	_curried        = make(map[*list.Element][]reflect.Value)
-	_eventIdCounter EventId
+	_default           = New() // the bus used by the package-level functions
+	_eventIdCounter    EventId
+	_listenerIdCounter ListenerID
+
+	_signalHook  atomic.Value // holds a SignalHook
+	_errorWriter atomic.Value // holds an errorWriterBox
+	_errorMutex  sync.Mutex   // serializes writes to the error writer
 )
 
+// SetErrorWriter() sets where the bus writes warnings about listeners that
+// couldn't be called, which is os.Stderr by default.
+func SetErrorWriter(w io.Writer) {
+	_errorWriter.Store(errorWriterBox{w})
+}
+
+// errorWriterBox lets _errorWriter hold writers of different types.
+type errorWriterBox struct {
+	w io.Writer
+}
+
+func printErrorf(format string, v ...interface{}) {
+	w := io.Writer(os.Stderr)
+	if box, ok := _errorWriter.Load().(errorWriterBox); ok && box.w != nil {
+		w = box.w
+	}
+	_errorMutex.Lock()
+	defer _errorMutex.Unlock()
+	fmt.Fprintf(w, format, v...)
+}
+
+// SignalHook is called at the start of every signal, and the function it
+// returns at the end. It's meant for profiling.
+type SignalHook func(eventType EventId) (end func())
+
+// SetSignalHook() sets the hook called around every signal. Passing nil
+// removes it.
+func SetSignalHook(hook SignalHook) {
+	_signalHook.Store(hook)
+}
+
 // NewEventId() uses an internal counter to return a new valid event
 // id. It's thread-safe, but shouldn't be mixed with explicitly
 // defined id's as the values could overlap.
@@ -38,7 +79,7 @@ func NewEventId() EventId {
 	return EventId(atomic.AddUint32((*uint32)(&_eventIdCounter), 1))
 }
 
-// Signal() calls all of the registered listeners for a given
+// Signal() calls all of the listeners registered on the bus for a given
 // event type, as long as the parameters exactly match the ones
 // that were passed into this function. For example, this works:
 //
@@ -49,8 +90,9 @@ func NewEventId() EventId {
 //      }
 //
 //      func main() {
-//          bus.AddListener(MyEventId, onEventTrigger)
-//          bus.Signal(MyEventId, "hello signals!")
+//          b := bus.New()
+//          b.AddListener(MyEventId, onEventTrigger)
+//          b.Signal(MyEventId, "hello signals!")
 //      }
 //
 // ...but if onMyEventTrigger() took anything except exactly
@@ -58,85 +100,488 @@ func NewEventId() EventId {
 // would be issued out to standard error.
 //
 // As long as the parameters line up, listeners can take any number
-// of parameters, including 0.
+// of parameters, including 0. Catch-all listeners are the exception; see
+// AddListener().
+//
+// Listeners wrapped with Handler() can also stop the signal from going any
+// further: if one returns true, no lower-priority listeners are called.
+// Wildcard listeners are called afterwards either way.
+//
+// If the bus has no listeners at all for the event type, the signal is
+// passed on to its parent, if it has one.
+//
+// Signal() returns the number of listeners that were called, not counting
+// wildcard listeners or any that were skipped because their parameters
+// didn't match, so 0 means nothing handled the event. Async listeners count
+// once their call has been queued.
 //
-func Signal(eventType EventId, params ...interface{}) {
-	listeners, ok := _bus[eventType]
-	if !ok || listeners.Len() == 0 {
-		return
+func (b *Bus) Signal(eventType EventId, params ...interface{}) int {
+	if hook, _ := _signalHook.Load().(SignalHook); hook != nil {
+		defer hook(eventType)()
+	}
+	b.mutex.RLock()
+	rec := b.recorder
+	b.mutex.RUnlock()
+	if rec != nil {
+		rec.record(eventType, params)
+	}
+	return b.signal(eventType, params)
+}
+
+// signal() calls the listeners for a signal, or passes it on to the
+// parent bus if there aren't any, then calls the wildcard listeners. It
+// returns the number of listeners called.
+func (b *Bus) signal(eventType EventId, params []interface{}) (called int) {
+	// the slices are never modified in place, so these are snapshots even
+	// if listeners add or remove others, including themselves, while being
+	// called
+	b.mutex.RLock()
+	listeners, wildcards, parent := b.listeners[eventType], b.wildcards, b.parent
+	b.mutex.RUnlock()
+	if len(listeners) > 0 {
+		called = dispatch(eventType, listeners, params)
+	} else if parent != nil {
+		called = parent.signal(eventType, params)
+	}
+	for _, w := range wildcards {
+		w.fn(eventType, params)
 	}
+	return called
+}
+
+// dispatch() calls each listener whose parameters match a signal's, in
+// order, until one of them consumes it. It returns the number of listeners
+// called.
+func dispatch(eventType EventId, listeners []*listener, params []interface{}) (called int) {
 	paramValues := make([]reflect.Value, len(params))
 	for i, param := range params {
 		paramValues[i] = reflect.ValueOf(param)
 	}
 	numParams := len(paramValues)
 loop:
-	for e := listeners.Front(); e != nil; e = e.Next() {
-		curriedValues := _curried[e]
+	for _, l := range listeners {
+		curriedValues := l.curry
 		numCurried := len(curriedValues)
+		if raw, ok := l.fn.(func(...interface{})); ok {
+			all := make([]interface{}, 0, numCurried+numParams)
+			for _, v := range curriedValues {
+				all = append(all, v.Interface())
+			}
+			all = append(all, params...)
+			called++
+			if l.async {
+				callAsync(eventType, func() { raw(all...) })
+			} else {
+				raw(all...)
+			}
+			continue loop
+		}
+		if raw, ok := l.fn.(func(...interface{}) (bool, error)); ok && l.handler {
+			all := make([]interface{}, 0, numCurried+numParams)
+			for _, v := range curriedValues {
+				all = append(all, v.Interface())
+			}
+			all = append(all, params...)
+			call := func() bool {
+				consumed, err := raw(all...)
+				return handled(eventType, consumed, err)
+			}
+			called++
+			if l.async {
+				callAsync(eventType, func() { call() })
+				continue loop
+			}
+			if call() {
+				break loop
+			}
+			continue loop
+		}
 		n := numParams + numCurried
-		f := reflect.ValueOf(e.Value)
+		f := reflect.ValueOf(l.fn)
 		t := f.Type()
 		if t.NumIn() != n {
-			fmt.Fprintf(os.Stderr, "invalid callback registerd for event type %d: "+
+			printErrorf("invalid callback registerd for event type %d: "+
 				"need %d parameters, but have %d\n", eventType, n, t.NumIn())
 			continue loop
 		}
 		allValues := make([]reflect.Value, n)
 		for i := 0; i < n; i++ {
-			in := t.In(i)
-			failed := false
+			var value reflect.Value
 			if i < numCurried {
-				if in != paramValues[i].Type() {
-					failed = true
-				} else {
-					allValues[i] = curriedValues[i]
-				}
+				value = curriedValues[i]
 			} else {
-				if in != paramValues[i-numCurried].Type() {
-					failed = true
-				} else {
-					allValues[i] = paramValues[i-numCurried]
-				}
+				value = paramValues[i-numCurried]
 			}
-			if failed {
+			if in := t.In(i); in != value.Type() {
 				// TODO: if it's convertible to the desired type, then convert it
-				fmt.Fprintf(os.Stderr, "invalid callback registered for event type %d: "+
+				printErrorf("invalid callback registered for event type %d: "+
 					"need %s parameter, but have %s\n",
-					eventType, paramValues[i].Type().Name(), t.In(i).Name())
+					eventType, value.Type().Name(), in.Name())
 				continue loop
 			}
+			allValues[i] = value
+		}
+		called++
+		if l.async {
+			callAsync(eventType, func() { f.Call(allValues) })
+			continue loop
+		}
+		results := f.Call(allValues)
+		if l.handler {
+			consumed, _ := results[0].Interface().(bool)
+			err, _ := results[1].Interface().(error)
+			if handled(eventType, consumed, err) {
+				break loop
+			}
 		}
-		f.Call(allValues)
 	}
+	return called
 }
 
-// AddListener() registers a handler for a given event type.
-func AddListener(eventType EventId, f interface{}, curry ...interface{}) error {
-	if reflect.ValueOf(f).Kind() != reflect.Func {
-		return errors.New("cannot register non-func callback")
+// BusHandler is a listener that can stop a signal from propagating. Create
+// one with Handler().
+type BusHandler struct {
+	f interface{}
+}
+
+// Handler() wraps a function that returns (bool, error) so that it can be
+// registered as a listener that stops propagation. If the function returns
+// true, the event is considered consumed and no lower-priority listeners are
+// called for that signal. A non-nil error is logged, but doesn't stop
+// propagation by itself. Handlers registered with AddAsyncListener() can't
+// stop propagation, since they aren't run until later.
+//
+//      bus.AddListenerWithPriority(ClickEvent, bus.Handler(func(x, y int) (bool, error) {
+//          return menu.Contains(x, y), nil
+//      }), 10)
+//
+func Handler(f interface{}) BusHandler {
+	return BusHandler{f}
+}
+
+// handled() reports whether a handler consumed an event, logging its error
+// if it returned one.
+func handled(eventType EventId, consumed bool, err error) bool {
+	if err != nil {
+		printErrorf("handler for event type %d failed: %s\n", eventType, err.Error())
 	}
-	eventBus, ok := _bus[eventType]
+	return consumed
+}
+
+var (
+	boolType  = reflect.TypeOf(false)
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// unwrapHandler() returns the function to register for a listener, and
+// whether it came from a BusHandler. It returns nil if f isn't usable.
+func unwrapHandler(f interface{}) (interface{}, bool) {
+	h, ok := f.(BusHandler)
 	if !ok {
-		eventBus = new(list.List)
-		_bus[eventType] = eventBus
+		if reflect.ValueOf(f).Kind() != reflect.Func {
+			return nil, false
+		}
+		return f, false
+	}
+	t := reflect.TypeOf(h.f)
+	if t == nil || t.Kind() != reflect.Func || t.NumOut() != 2 || t.Out(0) != boolType || t.Out(1) != errorType {
+		return nil, true
+	}
+	return h.f, true
+}
+
+// Bus is a set of listeners. The package-level functions all work on a
+// default bus, but subsystems such as the UI or physics can create their
+// own with New() to keep their events separate, optionally passing any they
+// don't handle on to a parent bus. A bus is safe to use from multiple
+// goroutines.
+type Bus struct {
+	mutex     sync.RWMutex            // protects everything below
+	listeners map[EventId][]*listener // listeners by event type, highest priority first
+	wildcards []*wildcardListener     // listeners for every event type
+	parent    *Bus                    // where to pass signals with no listeners
+	recorder  *recorder               // set while signals are being recorded
+}
+
+// wildcardListener is a single registered wildcard listener.
+type wildcardListener struct {
+	id ListenerID
+	fn func(eventType EventId, params []interface{})
+}
+
+// New() creates a new, empty bus with no parent.
+func New() *Bus {
+	return &Bus{listeners: make(map[EventId][]*listener)}
+}
+
+// SetParent() sets the bus that signals are passed on to when this one has
+// no listeners for them. Passing nil removes it. A parent that would make a
+// cycle is rejected with a warning.
+func (b *Bus) SetParent(parent *Bus) {
+	for p := parent; p != nil; p = p.getParent() {
+		if p == b {
+			printErrorf("cannot set bus parent: it would create a cycle\n")
+			return
+		}
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.parent = parent
+}
+
+func (b *Bus) getParent() *Bus {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.parent
+}
+
+// ListenerID identifies a registered listener, so that it can be removed
+// later with RemoveListenerByID(). 0 is never a valid ID.
+type ListenerID uint64
+
+// listener is a single registered listener.
+type listener struct {
+	id       ListenerID
+	fn       interface{}
+	curry    []reflect.Value
+	priority int
+	async    bool // queue calls up for the worker pool instead of making them in Signal()?
+	handler  bool // registered with Handler(), so it returns (bool, error)?
+}
+
+// AddListener() registers a handler for a given event type.
+// Any curried values are passed to the handler before the
+// signal's own parameters. The returned ID can be used to remove
+// the handler again; if f isn't a function, nothing is registered
+// and 0 is returned.
+//
+// A handler of type func(...interface{}) is a catch-all listener: it's
+// called for every signal of the event type, whatever its parameters,
+// with the curried values followed by the signal's parameters, and never
+// skipped for a mismatch. It's meant for code that only cares that an
+// event happened, or that forwards parameters it doesn't know the types
+// of, such as EventSequencer. Wrapped with Handler(), a handler of type
+// func(...interface{}) (bool, error) works the same way.
+func (b *Bus) AddListener(eventType EventId, f interface{}, curry ...interface{}) ListenerID {
+	return b.AddListenerWithPriority(eventType, f, 0, curry...)
+}
+
+// AddListenerWithPriority() registers a handler like AddListener(), but
+// lets you control the order handlers are called in: higher priorities
+// are called first, and handlers with the same priority are called in the
+// order they were added. AddListener() uses a priority of 0.
+func (b *Bus) AddListenerWithPriority(eventType EventId, f interface{}, priority int, curry ...interface{}) ListenerID {
+	return b.addListener(eventType, f, priority, false, curry)
+}
+
+func (b *Bus) addListener(eventType EventId, f interface{}, priority int, async bool, curry []interface{}) ListenerID {
+	fn, handler := unwrapHandler(f)
+	if fn == nil {
+		if handler {
+			printErrorf("cannot register handler for event type %d: "+
+				"it must be a func returning (bool, error)\n", eventType)
+		} else {
+			printErrorf("cannot register non-func callback for event type %d\n", eventType)
+		}
+		return 0
+	}
+	l := &listener{
+		id:       ListenerID(atomic.AddUint64((*uint64)(&_listenerIdCounter), 1)),
+		fn:       fn,
+		curry:    make([]reflect.Value, len(curry)),
+		priority: priority,
+		async:    async,
+		handler:  handler,
 	}
-	e := eventBus.PushBack(f)
-	curriedValues := make([]reflect.Value, len(curry))
 	for i, x := range curry {
-		curriedValues[i] = reflect.ValueOf(x)
+		l.curry[i] = reflect.ValueOf(x)
 	}
-	_curried[e] = curriedValues
-	return nil
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	old := b.listeners[eventType]
+	i := sort.Search(len(old), func(i int) bool { return old[i].priority < priority })
+	listeners := make([]*listener, 0, len(old)+1)
+	listeners = append(listeners, old[:i]...)
+	listeners = append(listeners, l)
+	b.listeners[eventType] = append(listeners, old[i:]...)
+	return l.id
+}
+
+// AddWildcardListener() registers a handler that's called for every signal
+// on the bus, whatever its event type, e.g. for logging or analytics. It
+// gets the signal's parameters as they were passed, so there's no need for
+// them to match. Wildcard listeners are called after the ones registered for
+// the event type, and even if one of those stopped propagation.
+func (b *Bus) AddWildcardListener(f func(eventType EventId, params []interface{})) ListenerID {
+	if f == nil {
+		printErrorf("cannot register nil wildcard listener\n")
+		return 0
+	}
+	w := &wildcardListener{
+		id: ListenerID(atomic.AddUint64((*uint64)(&_listenerIdCounter), 1)),
+		fn: f,
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	wildcards := make([]*wildcardListener, 0, len(b.wildcards)+1)
+	b.wildcards = append(append(wildcards, b.wildcards...), w)
+	return w.id
+}
+
+// RemoveWildcardListener() unregisters the wildcard listener with the
+// given ID.
+func (b *Bus) RemoveWildcardListener(id ListenerID) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for i, w := range b.wildcards {
+		if w.id == id {
+			wildcards := make([]*wildcardListener, 0, len(b.wildcards)-1)
+			wildcards = append(wildcards, b.wildcards[:i]...)
+			b.wildcards = append(wildcards, b.wildcards[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("event handler not found")
+}
+
+// AddListenerOnce() registers a handler like AddListener(), but removes
+// it again the first time it's called, before it runs. Signals that the
+// handler's parameters don't match don't count.
+func (b *Bus) AddListenerOnce(eventType EventId, f interface{}, curry ...interface{}) ListenerID {
+	fn, handler := unwrapHandler(f)
+	if fn == nil {
+		return b.AddListener(eventType, f, curry...)
+	}
+	v := reflect.ValueOf(fn)
+	var (
+		t     = v.Type()
+		id    ListenerID
+		fired int32
+	)
+	once := reflect.MakeFunc(t, func(args []reflect.Value) []reflect.Value {
+		if !atomic.CompareAndSwapInt32(&fired, 0, 1) {
+			results := make([]reflect.Value, t.NumOut())
+			for i := range results {
+				results[i] = reflect.Zero(t.Out(i))
+			}
+			return results
+		}
+		b.RemoveListenerByID(eventType, id)
+		if t.IsVariadic() {
+			return v.CallSlice(args)
+		}
+		return v.Call(args)
+	})
+	if handler {
+		id = b.AddListener(eventType, Handler(once.Interface()), curry...)
+	} else {
+		id = b.AddListener(eventType, once.Interface(), curry...)
+	}
+	return id
+}
+
+// AddConditionalListener() registers a handler like AddListener(), but only
+// calls it when predicate returns true. The predicate must take the same
+// parameters as the handler and return a bool; both are called with the
+// same values, including any curried ones:
+//
+//      bus.AddConditionalListener(ScoreEvent, func(player, points int) bool {
+//          return player == me
+//      }, func(player, points int) {
+//          total += points
+//      })
+//
+// Nothing is registered, and 0 is returned, if the predicate doesn't match
+// the handler. The handler can be wrapped with Handler(); if the predicate
+// returns false, it doesn't stop propagation.
+func (b *Bus) AddConditionalListener(eventType EventId, predicate, f interface{}, curry ...interface{}) ListenerID {
+	fn, handler := unwrapHandler(f)
+	if fn == nil {
+		return b.AddListener(eventType, f, curry...)
+	}
+	var (
+		v  = reflect.ValueOf(fn)
+		t  = v.Type()
+		pv = reflect.ValueOf(predicate)
+	)
+	if !matchesPredicate(pv, t) {
+		printErrorf("cannot register conditional listener for event type %d: "+
+			"predicate must take the same parameters as the handler and return bool\n", eventType)
+		return 0
+	}
+	guarded := reflect.MakeFunc(t, func(args []reflect.Value) []reflect.Value {
+		call, callPredicate := v.Call, pv.Call
+		if t.IsVariadic() {
+			call, callPredicate = v.CallSlice, pv.CallSlice
+		}
+		if !callPredicate(args)[0].Bool() {
+			results := make([]reflect.Value, t.NumOut())
+			for i := range results {
+				results[i] = reflect.Zero(t.Out(i))
+			}
+			return results
+		}
+		return call(args)
+	})
+	if handler {
+		return b.AddListener(eventType, Handler(guarded.Interface()), curry...)
+	}
+	return b.AddListener(eventType, guarded.Interface(), curry...)
+}
+
+// matchesPredicate() reports whether a predicate takes the same parameters
+// as a handler of type t, and returns a single bool.
+func matchesPredicate(predicate reflect.Value, t reflect.Type) bool {
+	if predicate.Kind() != reflect.Func {
+		return false
+	}
+	pt := predicate.Type()
+	if pt.NumOut() != 1 || pt.Out(0) != boolType || pt.NumIn() != t.NumIn() || pt.IsVariadic() != t.IsVariadic() {
+		return false
+	}
+	for i := 0; i < t.NumIn(); i++ {
+		if pt.In(i) != t.In(i) {
+			return false
+		}
+	}
+	return true
 }
 
 // RemoveListener() unregisters a handler for a given event type.
-func RemoveListener(eventType EventId, f interface{}) error {
-	listeners := _bus[eventType]
-	for e := listeners.Front(); e != nil; e = e.Next() {
-		if &e.Value == &f {
-			listeners.Remove(e)
-			delete(_curried, e)
+// Handlers are compared by their code, so this can't tell apart two
+// closures created by the same function literal, and removes the
+// first one registered; use RemoveListenerByID() for those.
+func (b *Bus) RemoveListener(eventType EventId, f interface{}) error {
+	if h, ok := f.(BusHandler); ok {
+		f = h.f
+	}
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func {
+		return errors.New("event handler not found")
+	}
+	return b.removeWhere(eventType, func(l *listener) bool {
+		return reflect.ValueOf(l.fn).Pointer() == v.Pointer()
+	})
+}
+
+// RemoveListenerByID() unregisters the handler with the given ID.
+func (b *Bus) RemoveListenerByID(eventType EventId, id ListenerID) error {
+	return b.removeWhere(eventType, func(l *listener) bool {
+		return l.id == id
+	})
+}
+
+// removeWhere() removes the first listener for an event type that
+// matches.
+func (b *Bus) removeWhere(eventType EventId, match func(*listener) bool) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	old := b.listeners[eventType]
+	for i, l := range old {
+		if match(l) {
+			listeners := make([]*listener, 0, len(old)-1)
+			listeners = append(listeners, old[:i]...)
+			b.listeners[eventType] = append(listeners, old[i+1:]...)
 			return nil
 		}
 	}
@@ -145,27 +590,124 @@ func RemoveListener(eventType EventId, f interface{}) error {
 
 // Clear() unregisters all handlers on the bus for a particular event type,
 // then immediately runs a garbage collection.
-func Clear(eventType EventId) {
-	listeners, ok := _bus[eventType]
-	if !ok {
-		return
+func (b *Bus) Clear(eventType EventId) {
+	b.mutex.Lock()
+	_, ok := b.listeners[eventType]
+	delete(b.listeners, eventType)
+	b.mutex.Unlock()
+	if ok {
+		runtime.GC()
 	}
-	for e := listeners.Front(); e != nil; e = e.Next() {
-		delete(_bus, eventType)
+}
+
+// ClearEventType() unregisters all handlers on the bus for a particular
+// event type, leaving every other event type alone. Unlike Clear(), it
+// doesn't run a garbage collection.
+func (b *Bus) ClearEventType(eventType EventId) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	delete(b.listeners, eventType)
+}
+
+// ClearEventTypes() unregisters all handlers on the bus for each of the
+// given event types, e.g. the ones for game logic when changing levels,
+// without running a garbage collection.
+func (b *Bus) ClearEventTypes(eventTypes ...EventId) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, eventType := range eventTypes {
+		delete(b.listeners, eventType)
 	}
-	listeners.Init()
-	runtime.GC()
 }
 
 // ClearAll() unregisters all handlers on the bus for all event types,
-// then immediately runs a garbage collection.
-func ClearAll() {
-	for eventType, listeners := range _bus {
-		for e := listeners.Front(); e != nil; e = e.Next() {
-			delete(_curried, e)
-		}
-		listeners.Init()
-		delete(_bus, eventType)
+// including wildcard listeners, then immediately runs a garbage
+// collection.
+func (b *Bus) ClearAll() {
+	b.mutex.Lock()
+	for eventType := range b.listeners {
+		delete(b.listeners, eventType)
 	}
+	b.wildcards = nil
+	b.mutex.Unlock()
 	runtime.GC()
 }
+
+// Signal() signals an event on the default bus, and returns the number of
+// listeners called; see (*Bus).Signal().
+func Signal(eventType EventId, params ...interface{}) int {
+	return _default.Signal(eventType, params...)
+}
+
+// AddListener() registers a handler on the default bus; see
+// (*Bus).AddListener().
+func AddListener(eventType EventId, f interface{}, curry ...interface{}) ListenerID {
+	return _default.AddListener(eventType, f, curry...)
+}
+
+// AddListenerWithPriority() registers a handler on the default bus; see
+// (*Bus).AddListenerWithPriority().
+func AddListenerWithPriority(eventType EventId, f interface{}, priority int, curry ...interface{}) ListenerID {
+	return _default.AddListenerWithPriority(eventType, f, priority, curry...)
+}
+
+// AddWildcardListener() registers a handler on the default bus that's
+// called for every signal; see (*Bus).AddWildcardListener().
+func AddWildcardListener(f func(eventType EventId, params []interface{})) ListenerID {
+	return _default.AddWildcardListener(f)
+}
+
+// RemoveWildcardListener() unregisters a wildcard listener from the default
+// bus by its ID.
+func RemoveWildcardListener(id ListenerID) error {
+	return _default.RemoveWildcardListener(id)
+}
+
+// AddListenerOnce() registers a one-shot handler on the default bus; see
+// (*Bus).AddListenerOnce().
+func AddListenerOnce(eventType EventId, f interface{}, curry ...interface{}) ListenerID {
+	return _default.AddListenerOnce(eventType, f, curry...)
+}
+
+// AddConditionalListener() registers a handler on the default bus that's
+// only called when predicate returns true; see
+// (*Bus).AddConditionalListener().
+func AddConditionalListener(eventType EventId, predicate, f interface{}, curry ...interface{}) ListenerID {
+	return _default.AddConditionalListener(eventType, predicate, f, curry...)
+}
+
+// RemoveListener() unregisters a handler from the default bus; see
+// (*Bus).RemoveListener().
+func RemoveListener(eventType EventId, f interface{}) error {
+	return _default.RemoveListener(eventType, f)
+}
+
+// RemoveListenerByID() unregisters a handler from the default bus by its
+// ID.
+func RemoveListenerByID(eventType EventId, id ListenerID) error {
+	return _default.RemoveListenerByID(eventType, id)
+}
+
+// Clear() unregisters all handlers on the default bus for a particular
+// event type, then immediately runs a garbage collection.
+func Clear(eventType EventId) {
+	_default.Clear(eventType)
+}
+
+// ClearAll() unregisters all handlers on the default bus for all event
+// types, then immediately runs a garbage collection.
+func ClearAll() {
+	_default.ClearAll()
+}
+
+// ClearEventType() unregisters all handlers on the default bus for a
+// particular event type, without running a garbage collection.
+func ClearEventType(eventType EventId) {
+	_default.ClearEventType(eventType)
+}
+
+// ClearEventTypes() unregisters all handlers on the default bus for each of
+// the given event types, without running a garbage collection.
+func ClearEventTypes(eventTypes ...EventId) {
+	_default.ClearEventTypes(eventTypes...)
+}
@@ -0,0 +1,303 @@
+package bus
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const testEvent EventId = 1
+
+func TestPriorityOrder(t *testing.T) {
+	tests := []struct {
+		priorities []int
+		want       []int // indexes into priorities, in call order
+	}{
+		{[]int{0, 0, 0}, []int{0, 1, 2}},
+		{[]int{0, 10, 5}, []int{1, 2, 0}},
+		{[]int{-1, 0, -1, 1}, []int{3, 1, 0, 2}},
+	}
+	for _, test := range tests {
+		var (
+			b     = New()
+			order []int
+		)
+		for i, priority := range test.priorities {
+			i := i
+			b.AddListenerWithPriority(testEvent, func() { order = append(order, i) }, priority)
+		}
+		b.Signal(testEvent)
+		if !reflect.DeepEqual(order, test.want) {
+			t.Errorf("priorities %v: called in order %v, want %v", test.priorities, order, test.want)
+		}
+	}
+}
+
+func TestAddListenerOnce(t *testing.T) {
+	var (
+		b     = New()
+		calls int
+	)
+	b.AddListenerOnce(testEvent, func(x int) { calls++ })
+	defer SetErrorWriter(nil)
+	SetErrorWriter(new(bytes.Buffer))
+
+	b.Signal(testEvent, "wrong type") // doesn't count
+	b.Signal(testEvent, 1)
+	b.Signal(testEvent, 2)
+	if calls != 1 {
+		t.Errorf("called %d times, want 1", calls)
+	}
+	if n := len(b.listeners[testEvent]); n != 0 {
+		t.Errorf("%d listeners left after firing, want 0", n)
+	}
+}
+
+func TestSignalCount(t *testing.T) {
+	var errs bytes.Buffer
+	SetErrorWriter(&errs)
+	defer SetErrorWriter(nil)
+
+	b := New()
+	if n := b.Signal(testEvent, 1); n != 0 {
+		t.Errorf("signal with no listeners reached %d, want 0", n)
+	}
+	b.AddListener(testEvent, func(x int) {})
+	b.AddListener(testEvent, func(x string) {})
+	b.AddListener(testEvent, func(x, y int) {})
+	b.AddListener(testEvent, func(args ...interface{}) {})
+	b.AddWildcardListener(func(EventId, []interface{}) {})
+	if n := b.Signal(testEvent, 1); n != 2 {
+		t.Errorf("signal reached %d listeners, want 2", n)
+	}
+	if !strings.Contains(errs.String(), "invalid callback") {
+		t.Errorf("expected warnings about mismatched listeners, got %q", errs.String())
+	}
+
+	child := New()
+	child.SetParent(b)
+	if n := child.Signal(testEvent, 1); n != 2 {
+		t.Errorf("signal passed to parent reached %d listeners, want 2", n)
+	}
+}
+
+func TestHandlerStopsPropagation(t *testing.T) {
+	var (
+		b     = New()
+		calls []string
+	)
+	b.AddListenerWithPriority(testEvent, Handler(func(x int) (bool, error) {
+		calls = append(calls, "handler")
+		return x > 0, nil
+	}), 10)
+	b.AddListener(testEvent, func(x int) { calls = append(calls, "listener") })
+	b.AddWildcardListener(func(EventId, []interface{}) { calls = append(calls, "wildcard") })
+
+	if n := b.Signal(testEvent, 0); n != 2 {
+		t.Errorf("unconsumed signal reached %d listeners, want 2", n)
+	}
+	if n := b.Signal(testEvent, 1); n != 1 {
+		t.Errorf("consumed signal reached %d listeners, want 1", n)
+	}
+	want := []string{"handler", "listener", "wildcard", "handler", "wildcard"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls were %v, want %v", calls, want)
+	}
+}
+
+func TestWildcardListener(t *testing.T) {
+	type signal struct {
+		event  EventId
+		params []interface{}
+	}
+	var (
+		b   = New()
+		got []signal
+	)
+	id := b.AddWildcardListener(func(event EventId, params []interface{}) {
+		got = append(got, signal{event, params})
+	})
+	b.Signal(testEvent, 1, "a")
+	b.Signal(testEvent + 1)
+	if err := b.RemoveWildcardListener(id); err != nil {
+		t.Fatal(err)
+	}
+	b.Signal(testEvent, 2, "b")
+	want := []signal{{testEvent, []interface{}{1, "a"}}, {testEvent + 1, nil}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wildcard got %v, want %v", got, want)
+	}
+}
+
+func TestConditionalListener(t *testing.T) {
+	var (
+		b     = New()
+		total int
+	)
+	b.AddConditionalListener(testEvent, func(player, points int) bool {
+		return player == 2
+	}, func(player, points int) {
+		total += points
+	})
+	b.Signal(testEvent, 1, 10)
+	b.Signal(testEvent, 2, 5)
+	if total != 5 {
+		t.Errorf("total is %d, want 5", total)
+	}
+
+	defer SetErrorWriter(nil)
+	SetErrorWriter(new(bytes.Buffer))
+	if id := b.AddConditionalListener(testEvent, func(int) bool { return true }, func(a, b int) {}); id != 0 {
+		t.Errorf("mismatched predicate was registered with ID %d", id)
+	}
+}
+
+func TestRemoveListener(t *testing.T) {
+	var (
+		b     = New()
+		calls int32
+	)
+	f := func() { atomic.AddInt32(&calls, 1) }
+	id := b.AddListener(testEvent, f)
+	b.AddListener(testEvent, f)
+	if err := b.RemoveListenerByID(testEvent, id); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.RemoveListenerByID(testEvent, id); err == nil {
+		t.Error("removing the same ID twice should fail")
+	}
+	b.Signal(testEvent)
+	if err := b.RemoveListener(testEvent, f); err != nil {
+		t.Fatal(err)
+	}
+	b.Signal(testEvent)
+	if calls != 1 {
+		t.Errorf("called %d times, want 1", calls)
+	}
+}
+
+func TestClearEventTypes(t *testing.T) {
+	var (
+		b     = New()
+		calls []EventId
+	)
+	for id := EventId(1); id <= 3; id++ {
+		id := id
+		b.AddListener(id, func() { calls = append(calls, id) })
+	}
+	b.ClearEventTypes(1, 3)
+	for id := EventId(1); id <= 3; id++ {
+		b.Signal(id)
+	}
+	if want := []EventId{2}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("called listeners for %v, want %v", calls, want)
+	}
+}
+
+func TestAsyncListener(t *testing.T) {
+	var (
+		b     = New()
+		calls int32
+	)
+	defer SetErrorWriter(nil)
+	SetErrorWriter(new(bytes.Buffer))
+	b.AddAsyncListener(testEvent, func(x int) {
+		if x < 0 {
+			panic("negative")
+		}
+		atomic.AddInt32(&calls, 1)
+	})
+	for i := -2; i < 5; i++ {
+		b.Signal(testEvent, i)
+	}
+	DrainAsync()
+	if calls != 5 {
+		t.Errorf("called %d times, want 5", calls)
+	}
+}
+
+type recordedPoint struct {
+	X, Y int
+}
+
+type unregistered struct {
+	Z int
+}
+
+func TestRecordReplay(t *testing.T) {
+	var errs bytes.Buffer
+	SetErrorWriter(&errs)
+	defer SetErrorWriter(nil)
+
+	const pointEvent EventId = 2
+	gob.Register(recordedPoint{})
+
+	var (
+		recording bytes.Buffer
+		b         = New()
+	)
+	b.StartRecording(&recording)
+	b.Signal(testEvent, 1, "a")
+	b.Signal(pointEvent, recordedPoint{3, 4})
+	b.Signal(pointEvent, unregistered{5})
+	b.Signal(testEvent, 2, "b")
+	if err := b.StopRecording(); err != nil {
+		t.Fatal(err)
+	}
+	b.Signal(testEvent, 3, "not recorded")
+	if !strings.Contains(errs.String(), "not recording") {
+		t.Errorf("expected a warning about the unregistered type, got %q", errs.String())
+	}
+
+	var got []interface{}
+	replay := New()
+	replay.AddListener(testEvent, func(n int, s string) { got = append(got, n, s) })
+	replay.AddListener(pointEvent, func(p recordedPoint) { got = append(got, p) })
+	if err := replay.Replay(&recording); err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{1, "a", recordedPoint{3, 4}, 2, "b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed %v, want %v", got, want)
+	}
+
+	if err := replay.Replay(strings.NewReader("garbage")); err == nil {
+		t.Error("replaying garbage should fail")
+	}
+}
+
+func TestParentReceivesUnhandledSignals(t *testing.T) {
+	var (
+		parent, child = New(), New()
+		calls         []string
+	)
+	child.SetParent(parent)
+	parent.AddListener(testEvent, func() { calls = append(calls, "parent") })
+
+	child.Signal(testEvent)
+	child.AddListener(testEvent, func() { calls = append(calls, "child") })
+	child.Signal(testEvent)
+	want := []string{"parent", "child"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls were %v, want %v", calls, want)
+	}
+}
+
+func TestSetParentRejectsCycles(t *testing.T) {
+	var errs bytes.Buffer
+	SetErrorWriter(&errs)
+	defer SetErrorWriter(nil)
+
+	a, b := New(), New()
+	a.SetParent(b)
+	b.SetParent(a)
+	if b.parent != nil {
+		t.Error("cycle was allowed")
+	}
+	if errs.Len() == 0 {
+		t.Error("expected a warning about the cycle")
+	}
+}
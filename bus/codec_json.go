@@ -0,0 +1,16 @@
+package bus
+
+import (
+	"encoding/json"
+)
+
+// jsonCodec is the default codec, which uses encoding/json.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
@@ -1,8 +1,23 @@
 package bus
 
 const (
-	_ = EventId(^uint(0) - iota)
+	_ = EventId(^uint32(0) - iota)
 
 	// Handler signature: func(cmd string)
 	ConsoleCommandEvent
+
+	// Handler signature: func(beat int)
+	BeatEvent
+
+	// Handler signature: func(frame int, inputs map[string][]byte)
+	LockstepInputEvent
+
+	// Handler signature: func(url string)
+	ScreenshotSharedEvent
+
+	// Handler signature: func(msg allegory.ChatMessage)
+	ChatMessageEvent
+
+	// Handler signature: func(typeName string)
+	ProcessLeakEvent
 )
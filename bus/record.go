@@ -0,0 +1,101 @@
+package bus
+
+import (
+	"encoding/gob"
+	"io"
+	"sync"
+)
+
+// recorder writes each signal on a bus to a gob stream.
+type recorder struct {
+	mutex sync.Mutex
+	enc   *gob.Encoder
+	err   error // the first error writing to the stream
+}
+
+// recordedSignal is a single signal in a recording.
+type recordedSignal struct {
+	Event  EventId
+	Params []interface{}
+}
+
+// StartRecording() starts writing every signal on the bus, with its
+// parameters, to w using encoding/gob, so that it can be played back later
+// with Replay(). Each parameter's type must be registered with
+// gob.Register(); the basic types already are. Signals with a parameter that
+// can't be encoded are left out of the recording with a warning. Starting a
+// new recording ends the previous one.
+func (b *Bus) StartRecording(w io.Writer) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.recorder = &recorder{enc: gob.NewEncoder(w)}
+}
+
+// StopRecording() stops recording signals on the bus, returning the first
+// error writing to the recording, if there was one.
+func (b *Bus) StopRecording() error {
+	b.mutex.Lock()
+	rec := b.recorder
+	b.recorder = nil
+	b.mutex.Unlock()
+	if rec == nil {
+		return nil
+	}
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+	return rec.err
+}
+
+// Replay() signals every event in a recording made with StartRecording(),
+// in order and with the same parameters. Since gob doesn't keep track of
+// pointers, parameters that were pointers are signalled as the values they
+// pointed to.
+func (b *Bus) Replay(r io.Reader) error {
+	dec := gob.NewDecoder(r)
+	for {
+		var s recordedSignal
+		if err := dec.Decode(&s); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		b.Signal(s.Event, s.Params...)
+	}
+}
+
+// record() adds a signal to the recording.
+func (rec *recorder) record(eventType EventId, params []interface{}) {
+	s := recordedSignal{Event: eventType, Params: params}
+	// try it on a throwaway encoder first, so a parameter that can't be
+	// encoded doesn't leave part of the signal in the recording
+	if err := gob.NewEncoder(io.Discard).Encode(&s); err != nil {
+		printErrorf("not recording event type %d: %s\n", eventType, err.Error())
+		return
+	}
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+	if rec.err != nil {
+		return
+	}
+	if err := rec.enc.Encode(&s); err != nil {
+		printErrorf("recording stopped: %s\n", err.Error())
+		rec.err = err
+	}
+}
+
+// StartRecording() starts recording every signal on the default bus to w;
+// see (*Bus).StartRecording().
+func StartRecording(w io.Writer) {
+	_default.StartRecording(w)
+}
+
+// StopRecording() stops recording signals on the default bus.
+func StopRecording() error {
+	return _default.StopRecording()
+}
+
+// Replay() signals every event in a recording on the default bus; see
+// (*Bus).Replay().
+func Replay(r io.Reader) error {
+	return _default.Replay(r)
+}
@@ -0,0 +1,158 @@
+package bus
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Codec encodes values for SerializeEvent() and DeserializeEvent(). The
+// default codec uses encoding/json. To use a more compact format such as
+// msgpack, wrap the library's functions in a Codec and pass it to
+// SetCodec(), e.g.
+//
+//    type msgpackCodec struct{}
+//
+//    func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+//        return msgpack.Marshal(v)
+//    }
+//
+//    func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+//        return msgpack.Unmarshal(data, v)
+//    }
+//
+// Both ends of the connection have to use the same codec.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	_codec      atomic.Value // holds a codecBox
+	_types      = make(map[string]func() interface{})
+	_typeNames  = make(map[reflect.Type]string)
+	_typesMutex sync.RWMutex
+)
+
+// codecBox lets _codec hold codecs of different types.
+type codecBox struct {
+	c Codec
+}
+
+func init() {
+	RegisterType("bool", func() interface{} { return new(bool) })
+	RegisterType("string", func() interface{} { return new(string) })
+	RegisterType("int", func() interface{} { return new(int) })
+	RegisterType("int8", func() interface{} { return new(int8) })
+	RegisterType("int16", func() interface{} { return new(int16) })
+	RegisterType("int32", func() interface{} { return new(int32) })
+	RegisterType("int64", func() interface{} { return new(int64) })
+	RegisterType("uint", func() interface{} { return new(uint) })
+	RegisterType("uint8", func() interface{} { return new(uint8) })
+	RegisterType("uint16", func() interface{} { return new(uint16) })
+	RegisterType("uint32", func() interface{} { return new(uint32) })
+	RegisterType("uint64", func() interface{} { return new(uint64) })
+	RegisterType("float32", func() interface{} { return new(float32) })
+	RegisterType("float64", func() interface{} { return new(float64) })
+}
+
+// SetCodec() replaces the codec used to serialize events. Passing nil
+// restores the default JSON codec. It's safe to call while events are being
+// serialized; each event is encoded or decoded entirely with one codec.
+func SetCodec(c Codec) {
+	_codec.Store(codecBox{c})
+}
+
+// codec() returns the codec used to serialize events.
+func codec() Codec {
+	if box, ok := _codec.Load().(codecBox); ok && box.c != nil {
+		return box.c
+	}
+	return jsonCodec{}
+}
+
+// RegisterType() makes a type available for event serialization. The
+// factory must return a pointer to a new zero value of the type, which
+// is what gets decoded into. Both the type and a pointer to it can then
+// be used as event parameters. The basic numeric types, bool and
+// string are registered automatically.
+func RegisterType(name string, factory func() interface{}) {
+	t := reflect.TypeOf(factory())
+	if t.Kind() != reflect.Ptr {
+		panic("RegisterType's `factory` must return a pointer!")
+	}
+	_typesMutex.Lock()
+	defer _typesMutex.Unlock()
+	_types[name] = factory
+	_typeNames[t] = name
+	_typeNames[t.Elem()] = name
+}
+
+type serializedEvent struct {
+	Event  EventId
+	Params []serializedParam
+}
+
+type serializedParam struct {
+	Type string
+	Ptr  bool
+	Data []byte
+}
+
+// SerializeEvent() encodes an event and its parameters so that it can be
+// sent over the network. Every parameter's type must be registered
+// with RegisterType().
+func SerializeEvent(eventType EventId, params []interface{}) ([]byte, error) {
+	var (
+		c     = codec()
+		event = serializedEvent{Event: eventType, Params: make([]serializedParam, len(params))}
+	)
+	_typesMutex.RLock()
+	defer _typesMutex.RUnlock()
+	for i, param := range params {
+		t := reflect.TypeOf(param)
+		name, ok := _typeNames[t]
+		if !ok {
+			return nil, fmt.Errorf("cannot serialize unregistered type %v", t)
+		}
+		data, err := c.Marshal(param)
+		if err != nil {
+			return nil, err
+		}
+		event.Params[i] = serializedParam{Type: name, Ptr: t.Kind() == reflect.Ptr, Data: data}
+	}
+	return c.Marshal(&event)
+}
+
+// DeserializeEvent() decodes an event encoded by SerializeEvent(). The
+// parameters have the same types they had when they were serialized, so
+// they can be passed straight to Signal().
+func DeserializeEvent(data []byte) (eventType EventId, params []interface{}, err error) {
+	var (
+		c     = codec()
+		event serializedEvent
+	)
+	if err = c.Unmarshal(data, &event); err != nil {
+		return 0, nil, err
+	}
+	_typesMutex.RLock()
+	defer _typesMutex.RUnlock()
+	params = make([]interface{}, len(event.Params))
+	for i, param := range event.Params {
+		factory, ok := _types[param.Type]
+		if !ok {
+			return 0, nil, fmt.Errorf("cannot deserialize unregistered type %s", param.Type)
+		}
+		v := factory()
+		if err = c.Unmarshal(param.Data, v); err != nil {
+			return 0, nil, err
+		}
+		if param.Ptr {
+			params[i] = v
+		} else {
+			params[i] = reflect.ValueOf(v).Elem().Interface()
+		}
+	}
+	return event.Event, params, nil
+}
@@ -0,0 +1,92 @@
+package bus
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type serializedPoint struct {
+	X, Y int
+}
+
+func init() {
+	RegisterType("bus.serializedPoint", func() interface{} { return new(serializedPoint) })
+}
+
+func TestSerializeRoundTrip(t *testing.T) {
+	params := []interface{}{1, "a", serializedPoint{2, 3}, &serializedPoint{4, 5}}
+	data, err := SerializeEvent(testEvent, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eventType, got, err := DeserializeEvent(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eventType != testEvent || !reflect.DeepEqual(got, params) {
+		t.Errorf("got event %d with %v, want %d with %v", eventType, got, testEvent, params)
+	}
+
+	if _, err := SerializeEvent(testEvent, []interface{}{struct{}{}}); err == nil {
+		t.Error("serializing an unregistered type should fail")
+	}
+}
+
+// countingCodec is a JSON codec that counts how many values it encodes.
+type countingCodec struct {
+	mutex sync.Mutex
+	n     int
+}
+
+func (c *countingCodec) Marshal(v interface{}) ([]byte, error) {
+	c.mutex.Lock()
+	c.n++
+	c.mutex.Unlock()
+	return json.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func TestSetCodec(t *testing.T) {
+	defer SetCodec(nil)
+	c := new(countingCodec)
+	SetCodec(c)
+	if _, err := SerializeEvent(testEvent, []interface{}{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if c.n != 3 {
+		t.Errorf("codec encoded %d values, want 3", c.n)
+	}
+
+	// swapping codecs while serializing mustn't race
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				data, err := SerializeEvent(testEvent, []interface{}{j})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if _, _, err := DeserializeEvent(data); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		if i%2 == 0 {
+			SetCodec(nil)
+		} else {
+			SetCodec(c)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dradtke/go-allegro/allegro"
+	"sort"
+)
+
+// DefaultAtlasWidth is the widest an atlas will be if no maximum is given.
+const DefaultAtlasWidth = 2048
+
+// atlasPadding is the number of empty pixels left between packed images
+// so that filtering doesn't bleed neighboring images into each other.
+const atlasPadding = 1
+
+var _atlases []*Atlas
+
+type Rect struct {
+	X, Y, W, H int
+}
+
+// Atlas is a single bitmap holding many smaller ones.
+type Atlas struct {
+	bitmap  *allegro.Bitmap
+	regions map[string]Rect
+	subs    map[string]*allegro.Bitmap
+}
+
+// Get() returns the sub-bitmap for a packed image, along with its
+// position within the atlas.
+func (a *Atlas) Get(name string) (*allegro.Bitmap, Rect, bool) {
+	bmp, ok := a.subs[name]
+	if !ok {
+		return nil, Rect{}, false
+	}
+	return bmp, a.regions[name], true
+}
+
+// Bitmap() returns the atlas bitmap itself.
+func (a *Atlas) Bitmap() *allegro.Bitmap {
+	return a.bitmap
+}
+
+// Destroy() destroys the atlas and every sub-bitmap in it.
+func (a *Atlas) Destroy() {
+	for name, sub := range a.subs {
+		sub.Destroy()
+		delete(a.subs, name)
+	}
+	a.bitmap.Destroy()
+}
+
+// AtlasPacker combines bitmaps into a single atlas using shelf packing:
+// images are sorted from tallest to shortest and laid out left to right
+// in rows.
+type AtlasPacker struct {
+	names   []string
+	bitmaps map[string]*allegro.Bitmap
+
+	// MaxWidth is the widest the atlas is allowed to be. If it's 0,
+	// DefaultAtlasWidth is used.
+	MaxWidth int
+}
+
+// Add() queues a bitmap to be packed under name.
+func (p *AtlasPacker) Add(name string, bmp *allegro.Bitmap) {
+	if p.bitmaps == nil {
+		p.bitmaps = make(map[string]*allegro.Bitmap)
+	}
+	if _, ok := p.bitmaps[name]; !ok {
+		p.names = append(p.names, name)
+	}
+	p.bitmaps[name] = bmp
+}
+
+// Build() packs every added bitmap into a new atlas. The original
+// bitmaps are copied, not modified.
+func (p *AtlasPacker) Build() (*Atlas, error) {
+	if len(p.names) == 0 {
+		return nil, errors.New("no images were added to the atlas")
+	}
+	maxWidth := p.MaxWidth
+	if maxWidth <= 0 {
+		maxWidth = DefaultAtlasWidth
+	}
+
+	names := make([]string, len(p.names))
+	copy(names, p.names)
+	sort.SliceStable(names, func(i, j int) bool {
+		return p.bitmaps[names[i]].Height() > p.bitmaps[names[j]].Height()
+	})
+
+	var (
+		regions     = make(map[string]Rect)
+		x, y        int
+		shelfHeight int
+		width       int
+	)
+	for _, name := range names {
+		bmp := p.bitmaps[name]
+		w, h := bmp.Width(), bmp.Height()
+		if w > maxWidth {
+			return nil, fmt.Errorf("image %s is wider than the atlas", name)
+		}
+		if x+w > maxWidth {
+			x, y = 0, y+shelfHeight+atlasPadding
+			shelfHeight = 0
+		}
+		regions[name] = Rect{x, y, w, h}
+		x += w + atlasPadding
+		if h > shelfHeight {
+			shelfHeight = h
+		}
+		if x > width {
+			width = x
+		}
+	}
+	height := y + shelfHeight
+
+	atlas := &Atlas{regions: regions, subs: make(map[string]*allegro.Bitmap)}
+	atlas.bitmap = allegro.CreateBitmap(width, height)
+	if atlas.bitmap == nil {
+		return nil, fmt.Errorf("failed to create %dx%d atlas", width, height)
+	}
+	atlas.bitmap.AsTarget(func() {
+		allegro.ClearToColor(allegro.MapRGBA(0, 0, 0, 0))
+		for name, r := range regions {
+			p.bitmaps[name].Draw(float32(r.X), float32(r.Y), allegro.FLIP_NONE)
+		}
+	})
+	for name, r := range regions {
+		atlas.subs[name] = atlas.bitmap.CreateSubBitmap(r.X, r.Y, r.W, r.H)
+	}
+	return atlas, nil
+}
+
+// Pack() combines cached images into a single atlas. The cached images are
+// replaced by sub-bitmaps of the atlas, so anything loaded afterwards
+// with Image() or FindImage() is drawn from it. Images that have already
+// been packed into another atlas can't be packed again.
+func Pack(keys []string) (*Atlas, error) {
+	var (
+		p      AtlasPacker
+		packed = packedImages()
+	)
+	for _, key := range keys {
+		bmp, err := FindImage(key)
+		if err != nil {
+			return nil, err
+		}
+		if packed[bmp] {
+			return nil, fmt.Errorf("image %s is already packed into an atlas", key)
+		}
+		p.Add(key, bmp)
+	}
+	atlas, err := p.Build()
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range p.names {
+		_images[key].Destroy()
+		_images[key] = atlas.subs[key]
+	}
+	_atlases = append(_atlases, atlas)
+	return atlas, nil
+}
+
+// packedImages() returns the set of sub-bitmaps in every atlas created with
+// Pack(), which are destroyed along with their atlas.
+func packedImages() map[*allegro.Bitmap]bool {
+	packed := make(map[*allegro.Bitmap]bool)
+	for _, atlas := range _atlases {
+		for _, sub := range atlas.subs {
+			packed[sub] = true
+		}
+	}
+	return packed
+}
@@ -17,12 +17,21 @@ func (e *ImageNotFound) Error() string {
 	return fmt.Sprintf("image %s not found", e.Key)
 }
 
-// ClearImages() removes all images from the cache.
+// ClearImages() removes all images from the cache, including any
+// atlases created with Pack().
 func ClearImages() {
+	packed := packedImages()
 	for key, val := range _images {
-		val.Destroy()
+		// packed images are destroyed along with their atlas
+		if !packed[val] {
+			val.Destroy()
+		}
 		delete(_images, key)
 	}
+	for _, atlas := range _atlases {
+		atlas.Destroy()
+	}
+	_atlases = nil
 }
 
 // LoadImage() loads an image into the cache.
@@ -0,0 +1,122 @@
+package allegory
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrProcessNotRunning is returned by CallProcess() when the process
+	// isn't running, or exits before answering.
+	ErrProcessNotRunning = errors.New("process isn't running")
+
+	// ErrNotCallable is returned by CallProcess() when the process doesn't
+	// implement Callable.
+	ErrNotCallable = errors.New("process isn't callable")
+
+	// ErrCallTimeout is returned by CallProcessWithTimeout() when the
+	// process doesn't answer in time.
+	ErrCallTimeout = errors.New("process call timed out")
+)
+
+// call asks a process to run its Call() method from its own goroutine.
+type call struct {
+	req   interface{}
+	reply chan callResult // buffered, so the process never blocks on it
+}
+
+type callResult struct {
+	value interface{}
+	err   error
+}
+
+// CallProcess() calls a process's Call() method with req from the process's
+// own goroutine, in between its ticks and messages, and waits for the
+// result. This makes it safe to read a process's state from elsewhere, e.g.
+// the player's health for the HUD:
+//
+//    health, err := CallProcess(player, HealthQuery{})
+//
+// Errors returned by Call() are passed back to the caller, and don't make
+// the process exit.
+func CallProcess(proc interface{}, req interface{}) (interface{}, error) {
+	return CallProcessWithTimeout(proc, req, 0)
+}
+
+// CallProcessWithTimeout() is like CallProcess(), but gives up and returns
+// ErrCallTimeout if the process hasn't answered within timeout. A timeout
+// of 0 or less waits forever.
+func CallProcessWithTimeout(proc interface{}, req interface{}, timeout time.Duration) (result interface{}, err error) {
+	if _, ok := proc.(Callable); !ok {
+		return nil, ErrNotCallable
+	}
+	_processMutex.Lock()
+	ch, ok := _messengers[proc]
+	_processMutex.Unlock()
+	if !ok {
+		return nil, ErrProcessNotRunning
+	}
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	done := WaitForProcess(proc)
+	c := &call{req: req, reply: make(chan callResult, 1)}
+
+	if !sendCall(ch, c, done, deadline) {
+		select {
+		case <-deadline:
+			return nil, ErrCallTimeout
+		default:
+			return nil, ErrProcessNotRunning
+		}
+	}
+	select {
+	case r := <-c.reply:
+		return r.value, r.err
+	case <-done:
+		// it may have answered right before exiting
+		select {
+		case r := <-c.reply:
+			return r.value, r.err
+		default:
+			return nil, ErrProcessNotRunning
+		}
+	case <-deadline:
+		return nil, ErrCallTimeout
+	}
+}
+
+// sendCall() sends a call to a process, returning false if the process
+// finished or the deadline passed first.
+func sendCall(ch chan interface{}, c *call, done <-chan struct{}, deadline <-chan time.Time) (sent bool) {
+	defer func() {
+		// don't let closed channels kill the program
+		if recover() != nil {
+			sent = false
+		}
+	}()
+	select {
+	case ch <- c:
+		return true
+	case <-done:
+		return false
+	case <-deadline:
+		return false
+	}
+}
+
+// answer() runs a call on proc and sends back the result.
+func (c *call) answer(proc interface{}) {
+	p, ok := proc.(Callable)
+	if !ok {
+		c.reply <- callResult{nil, ErrNotCallable}
+		return
+	}
+	end := profileProcess(proc, "Call")
+	value, err := p.Call(c.req)
+	end()
+	c.reply <- callResult{value, err}
+}
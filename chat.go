@@ -0,0 +1,189 @@
+package allegory
+
+import (
+	"errors"
+	"github.com/dradtke/allegory/bus"
+	"github.com/dradtke/go-allegro/allegro"
+	"github.com/dradtke/go-allegro/allegro/font"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultChatHistory is how many messages are kept per channel if
+// ChatProcess.HistorySize isn't set.
+const DefaultChatHistory = 100
+
+// ChatMessage is a single chat message.
+type ChatMessage struct {
+	Channel string
+	Sender  string
+	Text    string
+	Time    time.Time
+}
+
+/* -- ChatProcess -- */
+
+// ChatProcess keeps the recent history of each chat channel. Messages
+// sent locally go out through Transport; messages from other players
+// should be forwarded to the process by the networking code with
+// NotifyProcess(chat, &msg). Every message, whether local or remote,
+// is passed to OnMessage and signaled as a ChatMessageEvent.
+type ChatProcess struct {
+	mutex   sync.Mutex
+	history map[string]*chatHistory
+
+	// Sender is the name that local messages are sent under.
+	Sender string
+
+	// HistorySize is the number of messages to keep for each channel.
+	HistorySize int
+
+	// Transport sends a local message to the other players.
+	Transport func(msg ChatMessage) error
+
+	// OnMessage is called for each message as it arrives.
+	OnMessage func(msg ChatMessage)
+}
+
+// chatHistory is a ring buffer of messages.
+type chatHistory struct {
+	messages []ChatMessage
+	next     int
+	full     bool
+}
+
+// Send() sends a message to a channel.
+func (p *ChatProcess) Send(channel, text string) error {
+	if strings.TrimSpace(text) == "" {
+		return errors.New("chat message is empty")
+	}
+	msg := ChatMessage{Channel: channel, Sender: p.Sender, Text: text, Time: time.Now()}
+	if p.Transport != nil {
+		if err := p.Transport(msg); err != nil {
+			return err
+		}
+	}
+	p.receive(msg)
+	return nil
+}
+
+// History() returns up to the last n messages sent to a channel, oldest
+// first, or nil if n isn't positive.
+func (p *ChatProcess) History(channel string, n int) []ChatMessage {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	h, ok := p.history[channel]
+	if !ok {
+		return nil
+	}
+	count := h.next
+	if h.full {
+		count = len(h.messages)
+	}
+	if n > count {
+		n = count
+	}
+	if n <= 0 {
+		return nil
+	}
+	result := make([]ChatMessage, n)
+	for i := range result {
+		result[i] = h.messages[(h.next-n+i+len(h.messages))%len(h.messages)]
+	}
+	return result
+}
+
+func (p *ChatProcess) handleMessage(msg interface{}) error {
+	switch m := msg.(type) {
+	case *ChatMessage:
+		p.receive(*m)
+	case ChatMessage:
+		p.receive(m)
+	}
+	return nil
+}
+
+// receive() records a message and notifies anyone listening for it.
+func (p *ChatProcess) receive(msg ChatMessage) {
+	p.mutex.Lock()
+	if p.history == nil {
+		p.history = make(map[string]*chatHistory)
+	}
+	h, ok := p.history[msg.Channel]
+	if !ok {
+		size := p.HistorySize
+		if size <= 0 {
+			size = DefaultChatHistory
+		}
+		h = &chatHistory{messages: make([]ChatMessage, size)}
+		p.history[msg.Channel] = h
+	}
+	h.messages[h.next] = msg
+	h.next = (h.next + 1) % len(h.messages)
+	if h.next == 0 {
+		h.full = true
+	}
+	p.mutex.Unlock()
+
+	if p.OnMessage != nil {
+		p.OnMessage(msg)
+	}
+	bus.Signal(bus.ChatMessageEvent, msg)
+}
+
+/* -- ChatView -- */
+
+// ChatView is an actor that draws the most recent messages of a chat
+// channel, newest at the bottom.
+type ChatView struct {
+	// Chat is the process whose messages are shown.
+	Chat *ChatProcess
+
+	// Channel is the channel to show.
+	Channel string
+
+	// Lines is the number of messages to show.
+	Lines int
+
+	// X and Y are the position of the bottom-left corner.
+	X, Y float32
+
+	// Color is the text color.
+	Color allegro.Color
+
+	// Emoji, if not nil, maps shortcodes such as ":)" to the text they
+	// should be drawn as.
+	Emoji map[string]string
+}
+
+func (v *ChatView) Render(delta float32) {
+	if v.Chat == nil {
+		return
+	}
+	f := BuiltinFont()
+	messages := v.Chat.History(v.Channel, v.Lines)
+	for i, msg := range messages {
+		y := v.Y - float32((len(messages)-i)*(f.LineHeight()+2))
+		font.DrawText(f, v.Color, v.X, y, font.ALIGN_LEFT, msg.Sender+": "+v.parseEmoji(msg.Text))
+	}
+}
+
+// parseEmoji() replaces each emoji shortcode in text.
+func (v *ChatView) parseEmoji(text string) string {
+	if len(v.Emoji) == 0 {
+		return text
+	}
+	// longer codes go first so that e.g. ":))" wins over ":)"
+	codes := make([]string, 0, len(v.Emoji))
+	for code := range v.Emoji {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return len(codes[i]) > len(codes[j]) })
+	pairs := make([]string, 0, len(codes)*2)
+	for _, code := range codes {
+		pairs = append(pairs, code, v.Emoji[code])
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
@@ -0,0 +1,76 @@
+package allegory
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// ErrNoClipboard is returned by clipboard operations when none of the
+// platform's clipboard commands are available.
+var ErrNoClipboard = errors.New("no clipboard command available")
+
+// Clipboard provides access to the system clipboard. Each operation shells
+// out to the platform's clipboard tool, so they can take a noticeable
+// amount of time; use CopyAsync() and PasteAsync() from the game loop.
+type Clipboard struct{}
+
+// Copy() places text on the clipboard.
+func (Clipboard) Copy(text string) error {
+	cmd, err := clipboardCommand(_clipboardCopy)
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
+
+// Paste() returns the text currently on the clipboard.
+func (Clipboard) Paste() (string, error) {
+	cmd, err := clipboardCommand(_clipboardPaste)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out.String(), clipboardNewline), nil
+}
+
+// Clear() empties the clipboard.
+func (c Clipboard) Clear() error {
+	return c.Copy("")
+}
+
+// CopyAsync() calls Copy() in a separate goroutine, then calls done with
+// the result if it isn't nil.
+func (c Clipboard) CopyAsync(text string, done func(error)) {
+	go func() {
+		err := c.Copy(text)
+		if done != nil {
+			done(err)
+		}
+	}()
+}
+
+// PasteAsync() calls Paste() in a separate goroutine, then calls done with
+// the result.
+func (c Clipboard) PasteAsync(done func(string, error)) {
+	go func() {
+		done(c.Paste())
+	}()
+}
+
+// clipboardCommand() returns a command for the first candidate that's
+// installed.
+func clipboardCommand(candidates [][]string) (*exec.Cmd, error) {
+	for _, args := range candidates {
+		if _, err := exec.LookPath(args[0]); err == nil {
+			return exec.Command(args[0], args[1:]...), nil
+		}
+	}
+	return nil, ErrNoClipboard
+}
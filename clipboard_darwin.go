@@ -0,0 +1,9 @@
+package allegory
+
+// On macOS, pbcopy and pbpaste read and write the general NSPasteboard.
+var (
+	_clipboardCopy  = [][]string{{"pbcopy"}}
+	_clipboardPaste = [][]string{{"pbpaste"}}
+)
+
+const clipboardNewline = ""
@@ -0,0 +1,18 @@
+//go:build !darwin && !windows
+
+package allegory
+
+// On Linux and the BSDs, the clipboard is managed by xclip or xsel,
+// whichever's installed.
+var (
+	_clipboardCopy = [][]string{
+		{"xclip", "-in", "-selection", "clipboard"},
+		{"xsel", "--input", "--clipboard"},
+	}
+	_clipboardPaste = [][]string{
+		{"xclip", "-out", "-selection", "clipboard"},
+		{"xsel", "--output", "--clipboard"},
+	}
+)
+
+const clipboardNewline = ""
@@ -0,0 +1,16 @@
+package allegory
+
+// On Windows, PowerShell's clipboard cmdlets wrap OpenClipboard() and
+// SetClipboardData().
+var (
+	_clipboardCopy = [][]string{
+		{"powershell", "-NoProfile", "-Command", "$input | Set-Clipboard"},
+	}
+	_clipboardPaste = [][]string{
+		{"powershell", "-NoProfile", "-Command", "Get-Clipboard -Raw"},
+	}
+)
+
+// PowerShell terminates output with a newline that isn't part of the
+// clipboard contents.
+const clipboardNewline = "\r\n"
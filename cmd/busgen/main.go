@@ -0,0 +1,468 @@
+// Command busgen validates a package's bus events and generates typed
+// wrappers for them. It's meant to be run with go generate:
+//
+//	//go:generate busgen -in busevents.go
+//
+// The input file declares events as constants of type bus.EventId, each
+// documented with the signature its handlers must have, using the same
+// convention as the engine's own events:
+//
+//	const (
+//		// Handler signature: func(x, y float32)
+//		PlayerMoved bus.EventId = iota + 1
+//	)
+//
+// busgen checks that no two events share an ID, and that every listener
+// registered in the package, with bus.AddListener() or any of its
+// variants, matches its event's signature (or, for events without one, that all of its handlers
+// agree). If everything checks out, it writes Signal and On* functions for
+// each documented event to the output file, which defaults to the input
+// file's name with a _gen suffix.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	busPath         = "github.com/dradtke/allegory/bus"
+	signaturePrefix = "Handler signature:"
+)
+
+var (
+	in  = flag.String("in", "busevents.go", "the file declaring the events")
+	out = flag.String("out", "", "the file to generate; defaults to <in>_gen.go")
+)
+
+// event is a single event constant.
+type event struct {
+	name   string
+	pos    token.Position
+	value  string        // the constant's value, or "" if it couldn't be determined
+	sig    *ast.FuncType // the documented handler signature, if any
+	params []string      // the parameter types in sig
+}
+
+// listenerFunc describes a bus function, or Bus method, that registers
+// listeners.
+type listenerFunc struct {
+	handlers []int // the positions of the handler arguments
+	fixed    int   // the number of arguments before any curried ones
+}
+
+var listenerFuncs = map[string]listenerFunc{
+	"AddListener":             {[]int{1}, 2},
+	"AddListenerWithPriority": {[]int{1}, 3},
+	"AddListenerOnce":         {[]int{1}, 2},
+	"AddAsyncListener":        {[]int{1}, 2},
+	// the predicate takes the same parameters as the handler
+	"AddConditionalListener": {[]int{1, 2}, 3},
+}
+
+// listener is a single handler passed to one of the listenerFuncs.
+type listener struct {
+	pos    token.Position
+	params []string // the handler's parameter types, minus any curried ones
+}
+
+func main() {
+	flag.Parse()
+	if *out == "" {
+		*out = strings.TrimSuffix(*in, ".go") + "_gen.go"
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, *in, nil, parser.ParseComments)
+	if err != nil {
+		fatal(err)
+	}
+	busName := importName(file, busPath)
+	if busName == "" {
+		fatal(fmt.Errorf("%s doesn't import %s", *in, busPath))
+	}
+
+	events, errs := readEvents(fset, file)
+	errs = append(errs, checkUnique(events)...)
+
+	others, err := parsePackage(fset, filepath.Dir(*in), *in, *out)
+	if err != nil {
+		fatal(err)
+	}
+	errs = append(errs, checkListeners(fset, events, append(others, file))...)
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+
+	src, err := generate(file, busName, events)
+	if err != nil {
+		fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0644); err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "busgen:", err)
+	os.Exit(1)
+}
+
+// importName() returns the name a file imports path under, or "" if it
+// doesn't import it.
+func importName(file *ast.File, path string) string {
+	for _, spec := range file.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p == path {
+			if spec.Name != nil {
+				return spec.Name.Name
+			}
+			return filepath.Base(path)
+		}
+	}
+	return ""
+}
+
+// readEvents() finds every bus.EventId constant in file, using go/types to
+// work out their values. Only the bus package is made available to the type
+// checker, which is all the constants should need.
+func readEvents(fset *token.FileSet, file *ast.File) ([]*event, []error) {
+	info := &types.Info{Defs: make(map[*ast.Ident]types.Object)}
+	conf := types.Config{Importer: busImporter{}, Error: func(error) {}}
+	conf.Check(file.Name.Name, fset, []*ast.File{file}, info)
+
+	var (
+		events []*event
+		errs   []error
+	)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			spec := spec.(*ast.ValueSpec)
+			doc := spec.Doc
+			if doc == nil && len(gen.Specs) == 1 {
+				doc = gen.Doc
+			}
+			for _, name := range spec.Names {
+				obj, ok := info.Defs[name].(*types.Const)
+				if !ok || !isEventId(obj.Type()) || name.Name == "_" {
+					continue
+				}
+				e := &event{name: name.Name, pos: fset.Position(name.Pos())}
+				if obj.Val().Kind() != 0 {
+					e.value = obj.Val().ExactString()
+				} else {
+					errs = append(errs, fmt.Errorf("%s: can't determine the value of %s", e.pos, e.name))
+				}
+				if sig, err := readSignature(doc); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %s: %s", e.pos, e.name, err))
+				} else if sig != nil {
+					e.sig, e.params = sig, paramTypes(sig)
+				}
+				events = append(events, e)
+			}
+		}
+	}
+	return events, errs
+}
+
+// readSignature() parses the handler signature out of an event's doc
+// comment, returning nil if there isn't one.
+func readSignature(doc *ast.CommentGroup) (*ast.FuncType, error) {
+	if doc == nil {
+		return nil, nil
+	}
+	for _, line := range strings.Split(doc.Text(), "\n") {
+		i := strings.Index(line, signaturePrefix)
+		if i == -1 {
+			continue
+		}
+		text := strings.TrimSpace(line[i+len(signaturePrefix):])
+		expr, err := parser.ParseExpr(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid handler signature %q", text)
+		}
+		sig, ok := expr.(*ast.FuncType)
+		if !ok {
+			return nil, fmt.Errorf("handler signature %q isn't a function type", text)
+		}
+		if sig.Results != nil && len(sig.Results.List) > 0 {
+			return nil, fmt.Errorf("handler signature %q can't return anything", text)
+		}
+		return sig, nil
+	}
+	return nil, nil
+}
+
+// checkUnique() reports any events that share an ID.
+func checkUnique(events []*event) []error {
+	var errs []error
+	seen := make(map[string]*event)
+	for _, e := range events {
+		if e.value == "" {
+			continue
+		}
+		if first, ok := seen[e.value]; ok {
+			errs = append(errs, fmt.Errorf("%s: %s has the same ID (%s) as %s at %s",
+				e.pos, e.name, e.value, first.name, first.pos))
+			continue
+		}
+		seen[e.value] = e
+	}
+	return errs
+}
+
+// parsePackage() parses the other Go files in dir, skipping the input and
+// output files and any tests.
+func parsePackage(fset *token.FileSet, dir string, skip ...string) ([]*ast.File, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+outer:
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		for _, s := range skip {
+			if filepath.Clean(s) == filepath.Clean(path) {
+				continue outer
+			}
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// checkListeners() checks every listener registered for one of the events
+// against its signature, or if it doesn't have one, against the other
+// listeners for the event. Calls to a *Bus's methods can't be told apart
+// from calls to other types' without fully type-checking, so any call to
+// something named like one of the listenerFuncs is checked if its first
+// argument is one of the events.
+func checkListeners(fset *token.FileSet, events []*event, files []*ast.File) []error {
+	byName := make(map[string]*event)
+	for _, e := range events {
+		byName[e.name] = e
+	}
+	funcs := make(map[string]*ast.FuncType)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil {
+				funcs[fn.Name.Name] = fn.Type
+			}
+		}
+	}
+
+	listeners := make(map[string][]listener)
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			lf, ok := listenerCall(call.Fun)
+			if !ok || len(call.Args) < lf.fixed {
+				return true
+			}
+			id, ok := call.Args[0].(*ast.Ident)
+			if !ok || byName[id.Name] == nil {
+				return true
+			}
+			for _, i := range lf.handlers {
+				var sig *ast.FuncType
+				switch f := call.Args[i].(type) {
+				case *ast.FuncLit:
+					sig = f.Type
+				case *ast.Ident:
+					sig = funcs[f.Name]
+				}
+				if sig == nil {
+					// can't tell what it is without fully type-checking
+					continue
+				}
+				params := paramTypes(sig)
+				// curried arguments are passed before the event's parameters
+				if curried := len(call.Args) - lf.fixed; curried <= len(params) {
+					params = params[curried:]
+				}
+				pos := fset.Position(call.Args[i].Pos())
+				listeners[id.Name] = append(listeners[id.Name], listener{pos, params})
+			}
+			return true
+		})
+	}
+
+	var errs []error
+	for _, e := range events {
+		want, source := e.params, "its handler signature"
+		for i, l := range listeners[e.name] {
+			if e.sig == nil && i == 0 {
+				want, source = l.params, "the listener at "+l.pos.String()
+				continue
+			}
+			if !equal(l.params, want) {
+				errs = append(errs, fmt.Errorf("%s: listener for %s takes (%s), but %s takes (%s)",
+					l.pos, e.name, strings.Join(l.params, ", "), source, strings.Join(want, ", ")))
+			}
+		}
+	}
+	return errs
+}
+
+// generate() writes typed wrappers for each event with a signature.
+func generate(file *ast.File, busName string, events []*event) ([]byte, error) {
+	var body bytes.Buffer
+	for _, e := range events {
+		if e.sig == nil {
+			continue
+		}
+		var (
+			params = fieldList(e.sig)
+			names  []string
+		)
+		for _, p := range params {
+			names = append(names, p.name)
+		}
+		fmt.Fprintf(&body, "\n// Signal%s() signals %s, and returns the number of listeners called.\n", e.name, e.name)
+		fmt.Fprintf(&body, "func Signal%s(%s) int {\n", e.name, joinFields(params))
+		fmt.Fprintf(&body, "\treturn %s.Signal(%s)\n}\n", busName, strings.Join(append([]string{e.name}, names...), ", "))
+		fmt.Fprintf(&body, "\n// On%s() registers a handler for %s.\n", e.name, e.name)
+		fmt.Fprintf(&body, "func On%s(f func(%s)) %s.ListenerID {\n", e.name, joinFields(params), busName)
+		fmt.Fprintf(&body, "\treturn %s.AddListener(%s, f)\n}\n", busName, e.name)
+	}
+
+	// keep only the imports that the signatures use, plus the bus
+	var imports []string
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := filepath.Base(path)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if path == busPath || strings.Contains(body.String(), name+".") {
+			imports = append(imports, importSpec(spec))
+		}
+	}
+	sort.Strings(imports)
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by busgen from %s; DO NOT EDIT.\n\n", filepath.Base(*in))
+	fmt.Fprintf(&src, "package %s\n\nimport (\n\t%s\n)\n", file.Name.Name, strings.Join(imports, "\n\t"))
+	src.Write(body.Bytes())
+	return format.Source(src.Bytes())
+}
+
+type field struct {
+	name, typ string
+}
+
+// fieldList() returns the parameters of sig, naming any unnamed ones.
+func fieldList(sig *ast.FuncType) []field {
+	var fields []field
+	for _, f := range sig.Params.List {
+		typ := types.ExprString(f.Type)
+		if len(f.Names) == 0 {
+			fields = append(fields, field{fmt.Sprintf("p%d", len(fields)), typ})
+		}
+		for _, name := range f.Names {
+			fields = append(fields, field{name.Name, typ})
+		}
+	}
+	return fields
+}
+
+func joinFields(fields []field) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = f.name + " " + f.typ
+	}
+	return strings.Join(parts, ", ")
+}
+
+func importSpec(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name + " " + spec.Path.Value
+	}
+	return spec.Path.Value
+}
+
+// paramTypes() returns the type of each of sig's parameters.
+func paramTypes(sig *ast.FuncType) []string {
+	var params []string
+	for _, f := range fieldList(sig) {
+		params = append(params, f.typ)
+	}
+	return params
+}
+
+// listenerCall() returns the listenerFunc that fun refers to, if any.
+func listenerCall(fun ast.Expr) (listenerFunc, bool) {
+	sel, ok := fun.(*ast.SelectorExpr)
+	if !ok {
+		return listenerFunc{}, false
+	}
+	// anything other than the bus package is assumed to be a *Bus
+	lf, ok := listenerFuncs[sel.Sel.Name]
+	return lf, ok
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+/* -- type checking -- */
+
+var eventId *types.Named
+
+// busImporter stands in for the bus package so that the input file can be
+// type-checked without it being installed. Every other import comes from
+// the default importer, if it's available.
+type busImporter struct{}
+
+func (busImporter) Import(path string) (*types.Package, error) {
+	if path != busPath {
+		return importer.Default().Import(path)
+	}
+	pkg := types.NewPackage(busPath, "bus")
+	name := types.NewTypeName(token.NoPos, pkg, "EventId", nil)
+	eventId = types.NewNamed(name, types.Typ[types.Uint32], nil)
+	pkg.Scope().Insert(name)
+	pkg.MarkComplete()
+	return pkg, nil
+}
+
+func isEventId(t types.Type) bool {
+	return eventId != nil && types.Identical(t, eventId)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+const testEvents = `package game
+
+import "github.com/dradtke/allegory/bus"
+
+const (
+	// Handler signature: func(x, y float32)
+	PlayerMoved bus.EventId = iota + 1
+
+	// Handler signature: func(name string)
+	PlayerJoined
+
+	Untyped
+)
+
+const Duplicate bus.EventId = 2
+`
+
+const testListeners = `package game
+
+import "github.com/dradtke/allegory/bus"
+
+func onJoined(name string) {}
+
+func listen(b *bus.Bus) {
+	bus.AddListener(PlayerMoved, func(x, y float32) {})
+	bus.AddListener(PlayerMoved, func(id int, x, y float32) {}, 1)
+	bus.AddListener(PlayerMoved, func(x int) {})
+	bus.AddListenerWithPriority(PlayerMoved, func(x, y int) {}, 10)
+	bus.AddListenerOnce(PlayerJoined, onJoined)
+	bus.AddAsyncListener(PlayerJoined, func(id int) {})
+	bus.AddConditionalListener(PlayerJoined, func(name string) bool { return true }, func(name int) {})
+	b.AddListener(PlayerJoined, func() {})
+	b.AddListener(Untyped, func(a int) {})
+	b.AddListenerOnce(Untyped, func(a string) {})
+}
+`
+
+func parse(t *testing.T, fset *token.FileSet, name, src string) []*event {
+	file, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, errs := readEvents(fset, file)
+	for _, err := range errs {
+		t.Error(err)
+	}
+	return events
+}
+
+func TestCheckUnique(t *testing.T) {
+	events := parse(t, token.NewFileSet(), "busevents.go", testEvents)
+	errs := checkUnique(events)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "Duplicate has the same ID (2) as PlayerJoined") {
+		t.Errorf("got errors %v, want one about Duplicate", errs)
+	}
+}
+
+func TestCheckListeners(t *testing.T) {
+	fset := token.NewFileSet()
+	events := parse(t, fset, "busevents.go", testEvents)
+	file, err := parser.ParseFile(fset, "listeners.go", testListeners, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every listener whose line is listed here is registered with the wrong
+	// signature; the rest, including the curried one, are fine
+	want := []string{
+		"listeners.go:10:", // bus.AddListener
+		"listeners.go:11:", // bus.AddListenerWithPriority
+		"listeners.go:13:", // bus.AddAsyncListener
+		"listeners.go:14:", // the handler passed to bus.AddConditionalListener
+		"listeners.go:15:", // (*bus.Bus).AddListener
+		"listeners.go:17:", // disagrees with the other listener for Untyped
+	}
+	errs := checkListeners(fset, events, nil)
+	if len(errs) != 0 {
+		t.Errorf("got errors with no listeners: %v", errs)
+	}
+	errs = checkListeners(fset, events, []*ast.File{file})
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+	for i, err := range errs {
+		if !strings.HasPrefix(err.Error(), want[i]) {
+			t.Errorf("error %d is %q, want one at %s", i, err, want[i])
+		}
+	}
+}
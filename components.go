@@ -0,0 +1,106 @@
+package allegory
+
+import (
+	"sync"
+)
+
+// Component is a piece of behavior that can be attached to a ComponentProcess.
+type Component interface {
+	Init() error
+	Update() error
+	Cleanup()
+}
+
+// ComponentProcess is a process built out of components, which can be
+// added and removed while it's running. Each tick, every component is
+// updated in the order it was added.
+type ComponentProcess struct {
+	mutex      sync.Mutex
+	components []Component
+	running    bool
+}
+
+// AddComponent() attaches a component to the process. If the process is
+// already running, the component is initialized immediately.
+func (p *ComponentProcess) AddComponent(c Component) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.running {
+		if err := c.Init(); err != nil {
+			return err
+		}
+	}
+	p.components = append(p.components, c)
+	return nil
+}
+
+// RemoveComponent() detaches a component from the process, cleaning it
+// up if the process is running.
+func (p *ComponentProcess) RemoveComponent(c Component) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for i, x := range p.components {
+		if x == c {
+			p.components = append(p.components[:i], p.components[i+1:]...)
+			if p.running {
+				c.Cleanup()
+			}
+			return
+		}
+	}
+}
+
+// Components() returns a copy of the process's components.
+func (p *ComponentProcess) Components() []Component {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	components := make([]Component, len(p.components))
+	copy(components, p.components)
+	return components
+}
+
+// GetComponent() returns the first component of type T attached to the process.
+func GetComponent[T Component](p *ComponentProcess) (T, bool) {
+	for _, c := range p.Components() {
+		if c, ok := c.(T); ok {
+			return c, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+func (p *ComponentProcess) init() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for i, c := range p.components {
+		if err := c.Init(); err != nil {
+			// undo the ones that did initialize
+			for _, c := range p.components[:i] {
+				c.Cleanup()
+			}
+			return err
+		}
+	}
+	p.running = true
+	return nil
+}
+
+func (p *ComponentProcess) tick() (bool, error) {
+	for _, c := range p.Components() {
+		if err := c.Update(); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Cleanup() cleans up every component, in reverse order.
+func (p *ComponentProcess) Cleanup() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for i := len(p.components) - 1; i >= 0; i-- {
+		p.components[i].Cleanup()
+	}
+	p.running = false
+}
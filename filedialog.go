@@ -0,0 +1,52 @@
+package allegory
+
+import (
+	"github.com/dradtke/go-allegro/allegro/dialog"
+	"strings"
+)
+
+// FileFilter restricts a file dialog to files matching any of its
+// patterns, e.g. FileFilter{"Images", []string{"*.png", "*.jpg"}}.
+type FileFilter struct {
+	Name     string
+	Patterns []string
+}
+
+// ShowOpenFileDialog() shows the platform's native dialog for choosing an
+// existing file and returns the chosen path. It blocks until the dialog is
+// closed, and returns an empty path if the user cancelled it.
+//
+// The native dialog is required; there's no in-engine file browser to fall
+// back on, since one would need the game loop to keep running while this
+// blocks. That's not a problem in practice, because the engine won't start
+// without Allegro's native dialogs addon, but it does mean that a platform
+// with no native dialog of its own gets an error or an empty path here.
+func ShowOpenFileDialog(title string, filters []FileFilter) (string, error) {
+	return showFileDialog("", title, filters, dialog.FILECHOOSER_FILE_MUST_EXIST)
+}
+
+// ShowSaveFileDialog() shows the platform's native dialog for choosing
+// where to save a file and returns the chosen path. defaultName is
+// the file name that the dialog starts with. Like ShowOpenFileDialog(),
+// it blocks until the dialog is closed.
+func ShowSaveFileDialog(title string, defaultName string, filters []FileFilter) (string, error) {
+	return showFileDialog(defaultName, title, filters, dialog.FILECHOOSER_SAVE)
+}
+
+func showFileDialog(initialPath, title string, filters []FileFilter, mode dialog.FileChooserFlags) (string, error) {
+	// Allegro only accepts a single list of patterns, so the
+	// filters are merged together.
+	var patterns []string
+	for _, filter := range filters {
+		patterns = append(patterns, filter.Patterns...)
+	}
+	fc, err := dialog.CreateNativeFileDialog(initialPath, title, strings.Join(patterns, ";"), mode)
+	if err != nil {
+		return "", err
+	}
+	defer fc.Destroy()
+	if !dialog.ShowNativeFileDialog(_display, fc) || fc.Count() == 0 {
+		return "", nil
+	}
+	return fc.Path(0), nil
+}
@@ -8,6 +8,10 @@ import (
 // Fatal() shows an error message box, then quits the
 // game when the user clicks 'Close'.
 func Fatal(err error) {
+	if _mockDisplay {
+		Error(err)
+		Exit(1)
+	}
 	dialog.ShowNativeMessageBoxWithButtons(_display, "Application Error", "", err.Error(), []string{"Close"}, dialog.MESSAGEBOX_ERROR)
 	Exit(1)
 }
@@ -0,0 +1,154 @@
+package allegory
+
+import (
+	"sort"
+	"sync"
+)
+
+// RuleSet holds the parameters that define how a game plays, such as
+// "lives" or "enemy_speed". Game modes change them to alter the rules.
+type RuleSet struct {
+	values map[string]interface{}
+}
+
+// NewRuleSet() creates an empty rule set.
+func NewRuleSet() *RuleSet {
+	return &RuleSet{values: make(map[string]interface{})}
+}
+
+// Set() sets a rule.
+func (r *RuleSet) Set(name string, value interface{}) {
+	if r.values == nil {
+		r.values = make(map[string]interface{})
+	}
+	r.values[name] = value
+}
+
+// Get() returns the value of a rule.
+func (r *RuleSet) Get(name string) (interface{}, bool) {
+	value, ok := r.values[name]
+	return value, ok
+}
+
+// Int() returns the value of an int rule, or def if it isn't set.
+func (r *RuleSet) Int(name string, def int) int {
+	if value, ok := r.values[name].(int); ok {
+		return value
+	}
+	return def
+}
+
+// Float() returns the value of a float64 rule, or def if it isn't set.
+func (r *RuleSet) Float(name string, def float64) float64 {
+	if value, ok := r.values[name].(float64); ok {
+		return value
+	}
+	return def
+}
+
+// Bool() returns the value of a bool rule, or def if it isn't set.
+func (r *RuleSet) Bool(name string, def bool) bool {
+	if value, ok := r.values[name].(bool); ok {
+		return value
+	}
+	return def
+}
+
+// Copy() returns a copy of the rule set.
+func (r *RuleSet) Copy() *RuleSet {
+	c := NewRuleSet()
+	for name, value := range r.values {
+		c.values[name] = value
+	}
+	return c
+}
+
+// GameMode is a set of changes to the rules, such as a challenge mode that
+// halves the player's health.
+type GameMode struct {
+	// Name identifies the mode; two modes with the same name are the same mode.
+	Name string
+
+	// Priority determines the order in which modes are applied. Modes with
+	// higher priorities are applied later, so their changes win.
+	Priority int
+
+	// ModifyRules makes the mode's changes to the rules.
+	ModifyRules func(rules *RuleSet)
+}
+
+// GameModeManager combines any number of game modes that are active at the
+// same time into a single rule set.
+type GameModeManager struct {
+	mutex   sync.Mutex
+	base    *RuleSet
+	enabled []GameMode
+	rules   *RuleSet
+}
+
+// NewGameModeManager() creates a game mode manager with base as the rules
+// to use when no modes are enabled.
+func NewGameModeManager(base *RuleSet) *GameModeManager {
+	if base == nil {
+		base = NewRuleSet()
+	}
+	return &GameModeManager{base: base}
+}
+
+// EnableMode() enables a game mode. Enabling a mode that's already enabled
+// has no effect.
+func (m *GameModeManager) EnableMode(mode GameMode) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.indexOf(mode) != -1 {
+		return
+	}
+	m.enabled = append(m.enabled, mode)
+	sort.SliceStable(m.enabled, func(i, j int) bool {
+		return m.enabled[i].Priority < m.enabled[j].Priority
+	})
+	m.rules = nil
+}
+
+// DisableMode() disables a game mode.
+func (m *GameModeManager) DisableMode(mode GameMode) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if i := m.indexOf(mode); i != -1 {
+		m.enabled = append(m.enabled[:i], m.enabled[i+1:]...)
+		m.rules = nil
+	}
+}
+
+// IsEnabled() returns true if a game mode is enabled.
+func (m *GameModeManager) IsEnabled(mode GameMode) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.indexOf(mode) != -1
+}
+
+// Rules() returns the base rules with every enabled mode applied, lowest
+// priority first. Modes with the same priority are applied in the order
+// they were enabled. The result shouldn't be modified.
+func (m *GameModeManager) Rules() *RuleSet {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.rules == nil {
+		m.rules = m.base.Copy()
+		for _, mode := range m.enabled {
+			if mode.ModifyRules != nil {
+				mode.ModifyRules(m.rules)
+			}
+		}
+	}
+	return m.rules
+}
+
+func (m *GameModeManager) indexOf(mode GameMode) int {
+	for i, enabled := range m.enabled {
+		if enabled.Name == mode.Name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,70 @@
+package graphics
+
+import (
+	"github.com/dradtke/go-allegro/allegro"
+)
+
+// Layer is a single bitmap to be drawn by ImageProcessor.Composite().
+type Layer struct {
+	Bitmap *allegro.Bitmap
+	X, Y   float32
+}
+
+// ImageProcessor manipulates bitmaps at runtime. Every operation returns
+// a new bitmap and leaves the original untouched, so the caller is
+// responsible for destroying the result. Grayscale() touches every pixel
+// and can be slow for large images, so consider calling it from a
+// process rather than in the middle of a frame.
+type ImageProcessor struct{}
+
+// Tint() returns a copy of bmp multiplied by color.
+func (p *ImageProcessor) Tint(bmp *allegro.Bitmap, color allegro.Color) *allegro.Bitmap {
+	return allegro.CreateBitmap(bmp.Width(), bmp.Height()).AsTarget(func() {
+		allegro.ClearToColor(allegro.MapRGBA(0, 0, 0, 0))
+		bmp.DrawTinted(color, 0, 0, allegro.FLIP_NONE)
+	})
+}
+
+// Grayscale() returns a copy of bmp with all color removed.
+func (p *ImageProcessor) Grayscale(bmp *allegro.Bitmap) *allegro.Bitmap {
+	w, h := bmp.Width(), bmp.Height()
+	return allegro.CreateBitmap(w, h).AsTarget(func() {
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				r, g, b, a := bmp.Pixel(x, y).UnmapRGBAf()
+				// weighted by how sensitive the eye is to each channel
+				l := 0.299*r + 0.587*g + 0.114*b
+				allegro.PutPixel(x, y, allegro.MapRGBAf(l, l, l, a))
+			}
+		}
+	})
+}
+
+// Resize() returns a copy of bmp stretched to w by h pixels.
+func (p *ImageProcessor) Resize(bmp *allegro.Bitmap, w, h int) *allegro.Bitmap {
+	return allegro.CreateBitmap(w, h).AsTarget(func() {
+		allegro.ClearToColor(allegro.MapRGBA(0, 0, 0, 0))
+		bmp.DrawScaled(0, 0, float32(bmp.Width()), float32(bmp.Height()),
+			0, 0, float32(w), float32(h), allegro.FLIP_NONE)
+	})
+}
+
+// Composite() draws each layer in order, first at the bottom, onto a new
+// bitmap just big enough to hold all of them.
+func (p *ImageProcessor) Composite(layers []Layer) *allegro.Bitmap {
+	var w, h int
+	for _, layer := range layers {
+		if right := int(layer.X) + layer.Bitmap.Width(); right > w {
+			w = right
+		}
+		if bottom := int(layer.Y) + layer.Bitmap.Height(); bottom > h {
+			h = bottom
+		}
+	}
+	return allegro.CreateBitmap(w, h).AsTarget(func() {
+		allegro.ClearToColor(allegro.MapRGBA(0, 0, 0, 0))
+		for _, layer := range layers {
+			layer.Bitmap.Draw(layer.X, layer.Y, allegro.FLIP_NONE)
+		}
+	})
+}
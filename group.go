@@ -0,0 +1,98 @@
+package allegory
+
+import (
+	"sync"
+)
+
+// ProcessGroup is a set of related processes, such as all enemy AI,
+// that are started, paused and stopped together. Processes are removed
+// from the group automatically once they finish.
+type ProcessGroup struct {
+	mutex   sync.Mutex
+	wg      sync.WaitGroup
+	members []interface{}
+	running map[interface{}]bool
+}
+
+// Add() adds a process to the group. It isn't started until RunAll()
+// is called.
+func (g *ProcessGroup) Add(proc interface{}) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.members = append(g.members, proc)
+}
+
+// Members() returns a copy of the group's processes.
+func (g *ProcessGroup) Members() []interface{} {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	members := make([]interface{}, len(g.members))
+	copy(members, g.members)
+	return members
+}
+
+// RunAll() starts every process in the group that isn't already running.
+func (g *ProcessGroup) RunAll() {
+	var procs []interface{}
+	g.mutex.Lock()
+	if g.running == nil {
+		g.running = make(map[interface{}]bool)
+	}
+	for _, proc := range g.members {
+		if !g.running[proc] {
+			g.running[proc] = true
+			g.wg.Add(1)
+			procs = append(procs, proc)
+		}
+	}
+	g.mutex.Unlock()
+
+	for _, proc := range procs {
+		RunProcess(proc)
+		go g.watch(proc)
+	}
+}
+
+// CloseAll() closes every process in the group.
+func (g *ProcessGroup) CloseAll() {
+	for _, proc := range g.Members() {
+		Close(proc)
+	}
+}
+
+// PauseAll() stops the group's processes from being ticked until
+// ResumeAll() is called. They still receive other messages.
+func (g *ProcessGroup) PauseAll() {
+	g.setPaused(true)
+}
+
+// ResumeAll() resumes the group's processes after PauseAll().
+func (g *ProcessGroup) ResumeAll() {
+	g.setPaused(false)
+}
+
+// WaitAll() blocks until every process started by RunAll() has finished.
+func (g *ProcessGroup) WaitAll() {
+	g.wg.Wait()
+}
+
+func (g *ProcessGroup) setPaused(paused bool) {
+	for _, proc := range g.Members() {
+		setProcessPaused(proc, paused)
+	}
+}
+
+// watch() removes a process from the group once it finishes.
+func (g *ProcessGroup) watch(proc interface{}) {
+	<-WaitForProcess(proc)
+	g.mutex.Lock()
+	delete(g.running, proc)
+	for i, member := range g.members {
+		if member == proc {
+			g.members = append(g.members[:i], g.members[i+1:]...)
+			break
+		}
+	}
+	g.mutex.Unlock()
+	g.wg.Done()
+}
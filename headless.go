@@ -0,0 +1,62 @@
+package allegory
+
+import (
+	"fmt"
+	"github.com/dradtke/allegory/config"
+	"time"
+)
+
+// RunHeadless() runs the game without a display, which is useful for game
+// servers and automated tests. No Allegro addons are installed and
+// nothing is rendered, but processes are still ticked and states and
+// actors are still updated at the configured frame rate. Since there is
+// no window to close, it returns once the last state has been popped and
+// any processes still running have been told to quit and have finished.
+func RunHeadless(initialState StateID) error {
+	if _, ok := _stateMap[initialState]; !ok {
+		return fmt.Errorf("allegory.RunHeadless() called with invalid state id: %s", initialState)
+	}
+
+	initializeState()
+	PushState(initialState)
+	handleLaunchURLs()
+
+	go readStdin()
+
+	step := time.Duration(float64(time.Second) / float64(config.Fps()))
+	ticker := time.NewTicker(step)
+	defer ticker.Stop()
+
+	for !_state.Empty() {
+		<-ticker.C
+		endFrame := profile("Frame")
+		update(1)
+		evaluateSignals()
+		endFrame()
+		profileFrame()
+	}
+
+	stopPoppedProcesses()
+	shutdown()
+	return nil
+}
+
+// stopPoppedProcesses() tells any processes still running in states that
+// have been popped to quit, and waits for them to finish. Popping a state
+// doesn't stop its processes, so without this the last state's processes
+// would keep running after RunHeadless() returns.
+func stopPoppedProcesses() {
+	var procs []interface{}
+	_processMutex.Lock()
+	for _, stateProcs := range _processes {
+		procs = append(procs, stateProcs...)
+	}
+	_processMutex.Unlock()
+
+	for _, proc := range procs {
+		Close(proc)
+	}
+	for _, proc := range procs {
+		<-WaitForProcess(proc)
+	}
+}
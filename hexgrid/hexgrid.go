@@ -0,0 +1,137 @@
+// Package hexgrid provides coordinate math for hexagonal grids.
+//
+// Cells are addressed by (col, row) pairs using "odd" offset coordinates:
+// for pointy-top grids every odd row is shoved right by half a hex,
+// and for flat-top grids every odd column is shoved down by half a hex.
+package hexgrid
+
+import (
+	"math"
+)
+
+var sqrt3 = float32(math.Sqrt(3))
+
+// HexGrid describes the layout of a hexagonal grid.
+type HexGrid struct {
+	// Size is the distance from the center of a hex to any of its corners.
+	Size float32
+
+	// PointyTop lays the hexes out with a corner at the top instead
+	// of a flat edge.
+	PointyTop bool
+}
+
+// Offset() returns the pixel position of the center of a hex.
+func (g *HexGrid) Offset(col, row int) (x, y float32) {
+	if g.PointyTop {
+		return g.Size * sqrt3 * (float32(col) + 0.5*float32(row&1)), g.Size * 1.5 * float32(row)
+	}
+	return g.Size * 1.5 * float32(col), g.Size * sqrt3 * (float32(row) + 0.5*float32(col&1))
+}
+
+// HexAt() returns the hex that contains a pixel position.
+func (g *HexGrid) HexAt(px, py float32) (col, row int) {
+	var q, r float32
+	if g.PointyTop {
+		q = (sqrt3/3*px - py/3) / g.Size
+		r = (2 * py / 3) / g.Size
+	} else {
+		q = (2 * px / 3) / g.Size
+		r = (-px/3 + sqrt3/3*py) / g.Size
+	}
+	aq, ar := roundAxial(q, r)
+	return g.fromAxial(aq, ar)
+}
+
+// Corners() returns the pixel positions of the six corners of a hex.
+func (g *HexGrid) Corners(col, row int) [6][2]float32 {
+	cx, cy := g.Offset(col, row)
+	var corners [6][2]float32
+	for i := range corners {
+		angle := math.Pi / 3 * float64(i)
+		if g.PointyTop {
+			angle -= math.Pi / 6
+		}
+		corners[i] = [2]float32{
+			cx + g.Size*float32(math.Cos(angle)),
+			cy + g.Size*float32(math.Sin(angle)),
+		}
+	}
+	return corners
+}
+
+var axialDirections = [6][2]int{{1, 0}, {1, -1}, {0, -1}, {-1, 0}, {-1, 1}, {0, 1}}
+
+// Neighbors() returns the six hexes that share an edge with the given one.
+func (g *HexGrid) Neighbors(col, row int) [][2]int {
+	q, r := g.toAxial(col, row)
+	neighbors := make([][2]int, 0, 6)
+	for _, d := range axialDirections {
+		c, rw := g.fromAxial(q+d[0], r+d[1])
+		neighbors = append(neighbors, [2]int{c, rw})
+	}
+	return neighbors
+}
+
+// Distance() returns the number of steps needed to walk from one hex to another.
+func (g *HexGrid) Distance(ax, ay, bx, by int) int {
+	aq, ar := g.toAxial(ax, ay)
+	bq, br := g.toAxial(bx, by)
+	dq, dr := aq-bq, ar-br
+	return (abs(dq) + abs(dr) + abs(dq+dr)) / 2
+}
+
+// Ring() returns every hex exactly radius steps away from the center hex.
+func (g *HexGrid) Ring(cx, cy, radius int) [][2]int {
+	if radius <= 0 {
+		return [][2]int{{cx, cy}}
+	}
+	q, r := g.toAxial(cx, cy)
+	q, r = q+axialDirections[4][0]*radius, r+axialDirections[4][1]*radius
+	ring := make([][2]int, 0, 6*radius)
+	for side := 0; side < 6; side++ {
+		for step := 0; step < radius; step++ {
+			c, rw := g.fromAxial(q, r)
+			ring = append(ring, [2]int{c, rw})
+			q, r = q+axialDirections[side][0], r+axialDirections[side][1]
+		}
+	}
+	return ring
+}
+
+// toAxial() converts offset coordinates to axial coordinates, which make
+// the rest of the math much simpler.
+func (g *HexGrid) toAxial(col, row int) (q, r int) {
+	if g.PointyTop {
+		return col - (row-(row&1))/2, row
+	}
+	return col, row - (col-(col&1))/2
+}
+
+// fromAxial() converts axial coordinates back to offset coordinates.
+func (g *HexGrid) fromAxial(q, r int) (col, row int) {
+	if g.PointyTop {
+		return q + (r-(r&1))/2, r
+	}
+	return q, r + (q-(q&1))/2
+}
+
+// roundAxial() rounds fractional axial coordinates to the nearest hex.
+func roundAxial(q, r float32) (int, int) {
+	s := -q - r
+	rq, rr, rs := math.Round(float64(q)), math.Round(float64(r)), math.Round(float64(s))
+	dq, dr, ds := math.Abs(rq-float64(q)), math.Abs(rr-float64(r)), math.Abs(rs-float64(s))
+	if dq > dr && dq > ds {
+		rq = -rr - rs
+	} else if dr > ds {
+		rr = -rq - rs
+	}
+	return int(rq), int(rr)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
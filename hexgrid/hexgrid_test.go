@@ -0,0 +1,65 @@
+package hexgrid
+
+import (
+	"testing"
+)
+
+var grids = []HexGrid{
+	{Size: 16, PointyTop: true},
+	{Size: 16, PointyTop: false},
+	{Size: 7.5, PointyTop: true},
+}
+
+func TestOffsetHexAtRoundTrip(t *testing.T) {
+	for _, g := range grids {
+		for row := -5; row <= 5; row++ {
+			for col := -5; col <= 5; col++ {
+				x, y := g.Offset(col, row)
+				if c, r := g.HexAt(x, y); c != col || r != row {
+					t.Errorf("%+v: (%d, %d) -> (%g, %g) -> (%d, %d)", g, col, row, x, y, c, r)
+				}
+			}
+		}
+	}
+}
+
+func TestNeighborsAreOneStepAway(t *testing.T) {
+	for _, g := range grids {
+		for _, cell := range [][2]int{{0, 0}, {3, 4}, {4, 3}, {-2, -1}} {
+			neighbors := g.Neighbors(cell[0], cell[1])
+			if len(neighbors) != 6 {
+				t.Fatalf("%+v: %v has %d neighbors", g, cell, len(neighbors))
+			}
+			seen := make(map[[2]int]bool)
+			for _, n := range neighbors {
+				if d := g.Distance(cell[0], cell[1], n[0], n[1]); d != 1 {
+					t.Errorf("%+v: neighbor %v of %v is %d steps away", g, n, cell, d)
+				}
+				seen[n] = true
+			}
+			if len(seen) != 6 {
+				t.Errorf("%+v: %v has duplicate neighbors: %v", g, cell, neighbors)
+			}
+		}
+	}
+}
+
+func TestRing(t *testing.T) {
+	for _, g := range grids {
+		for radius := 0; radius <= 4; radius++ {
+			ring := g.Ring(2, 3, radius)
+			want := 6 * radius
+			if radius == 0 {
+				want = 1
+			}
+			if len(ring) != want {
+				t.Errorf("%+v: ring of radius %d has %d hexes, want %d", g, radius, len(ring), want)
+			}
+			for _, h := range ring {
+				if d := g.Distance(2, 3, h[0], h[1]); d != radius {
+					t.Errorf("%+v: %v in ring of radius %d is %d steps away", g, h, radius, d)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package hexgrid
+
+import (
+	"github.com/dradtke/go-allegro/allegro"
+	"github.com/dradtke/go-allegro/allegro/primitives"
+)
+
+// HexGridView is an actor that draws the outline of every hex in a
+// rectangular region of a grid.
+type HexGridView struct {
+	Grid       *HexGrid
+	Cols, Rows int
+
+	// X and Y offset the whole grid on screen.
+	X, Y float32
+
+	Color     allegro.Color
+	Thickness float32
+}
+
+func (v *HexGridView) Render(delta float32) {
+	for row := 0; row < v.Rows; row++ {
+		for col := 0; col < v.Cols; col++ {
+			corners := v.Grid.Corners(col, row)
+			for i := range corners {
+				a, b := corners[i], corners[(i+1)%len(corners)]
+				primitives.DrawLine(
+					primitives.Point{X: v.X + a[0], Y: v.Y + a[1]},
+					primitives.Point{X: v.X + b[0], Y: v.Y + b[1]},
+					v.Color, v.Thickness)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package allegory
+
+// RunProcessAsChild() runs a process like RunProcess(), but makes it a
+// child of parent: when the parent exits for any reason, the child is
+// closed along with it, as are the child's own children. The child's
+// successor, if any, isn't started when it's closed this way. If the
+// parent isn't running, an error is logged and the child isn't started.
+func RunProcessAsChild(parent, child interface{}, opts ...ProcessOption) *ProcessHandle {
+	_processMutex.Lock()
+	_, running := _processStats[parent]
+	_processMutex.Unlock()
+	if !running {
+		Errorf("can't start child of a process that isn't running")
+		done := make(chan struct{})
+		close(done)
+		return &ProcessHandle{proc: child, done: done}
+	}
+	handle := RunProcess(child, opts...)
+
+	_processMutex.Lock()
+	_, parentAlive := _processStats[parent]
+	_, childAlive := _processStats[child]
+	if parentAlive && childAlive {
+		_children[parent] = append(_children[parent], child)
+	}
+	_processMutex.Unlock()
+
+	if !parentAlive {
+		// the parent finished while the child was starting
+		handle.Close()
+	}
+	return handle
+}
+
+// Children() returns the running children of a process.
+func Children(parent interface{}) []interface{} {
+	_processMutex.Lock()
+	defer _processMutex.Unlock()
+	return append([]interface{}(nil), _children[parent]...)
+}
+
+// CloseSubtree() closes a process along with all of its children, their
+// children, and so on. The deepest processes are closed first.
+func CloseSubtree(parent interface{}) {
+	_processMutex.Lock()
+	var (
+		subtree []interface{}
+		visit   func(proc interface{})
+	)
+	visit = func(proc interface{}) {
+		for _, child := range _children[proc] {
+			visit(child)
+		}
+		subtree = append(subtree, proc)
+	}
+	visit(parent)
+	_processMutex.Unlock()
+
+	for _, proc := range subtree {
+		Close(proc)
+	}
+}
+
+// takeChildren() removes a process from its parent's children and returns
+// its own, which are no longer tracked. The caller must hold
+// _processMutex.
+func takeChildren(proc interface{}) []interface{} {
+	for parent, children := range _children {
+		for i, child := range children {
+			if child == proc {
+				_children[parent] = append(children[:i], children[i+1:]...)
+				break
+			}
+		}
+		if len(_children[parent]) == 0 {
+			delete(_children, parent)
+		}
+	}
+	children := _children[proc]
+	delete(_children, proc)
+	return children
+}
@@ -11,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 )
 
 // Init() initializes the game by creating the event queue, installing
@@ -99,11 +100,23 @@ func initialize(state *gameState) {
 	_eventQueue.Register(_fpsTimer)
 	_fpsTimer.Start()
 
+	initializeState()
+}
+
+// initializeState() sets up the engine's internal bookkeeping, none of
+// which depends on Allegro.
+func initializeState() {
 	_state = stateStack{list.New()}
 	_processes = make(map[*gameState][]interface{})
 	_actors = make(map[*gameState][]interface{})
 	_actorLayers = make(map[*gameState]map[uint][]interface{})
 	_actorStates = make(map[interface{}]interface{})
+	_actorLastUpdate = make(map[interface{}]time.Time)
+	_processStats = make(map[interface{}]*ProcessStats)
+	_processDone = make(map[interface{}]chan struct{})
+	_migrations = make(map[*gameState][]interface{})
+	_children = make(map[interface{}][]interface{})
+	_processNames = make(map[string]interface{})
 	_messengers = make(map[interface{}]chan interface{})
 	_pressedKeys = make(map[allegro.KeyCode]bool)
 }
@@ -131,6 +144,12 @@ func cleanup() {
 // Run() initializes Allegro and Allegory and kicks off the main game loop.
 // It won't return until the game ends.
 func Run(initialState StateID) {
+	if _mockDisplay {
+		if err := RunHeadless(initialState); err != nil {
+			Error(err)
+		}
+		return
+	}
 	allegro.Run(func() {
 		defer cleanup()
 		state, ok := _stateMap[initialState]
@@ -140,6 +159,7 @@ func Run(initialState StateID) {
 		}
 		initialize(state)
 		PushState(initialState)
+		handleLaunchURLs()
 		loop()
 	})
 }
@@ -0,0 +1,48 @@
+package allegory
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// InjectDependencies() fills in the fields of a process that declare a
+// dependency on a named process, e.g.
+//
+//    type Player struct {
+//        Audio *AudioManager `inject:"audio"`
+//    }
+//
+// will have its Audio field set to whatever process was run with
+// RunNamedProcess("audio", ...). Fields can be of the dependency's own
+// type or any interface it implements. An error is returned if a dependency
+// isn't running or isn't of the right type, or if the field is unexported.
+//
+// RunProcess() calls this automatically before initializing a process, so
+// any dependencies need to be started first.
+func InjectDependencies(p interface{}) error {
+	v := reflect.ValueOf(p)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	v = v.Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := field.Tag.Lookup("inject")
+		if !ok {
+			continue
+		}
+		if field.PkgPath != "" {
+			return fmt.Errorf("%s.%s: can't inject into an unexported field", t.Name(), field.Name)
+		}
+		dep := FindProcess(name)
+		if dep == nil {
+			return fmt.Errorf("%s.%s: missing dependency '%s'", t.Name(), field.Name, name)
+		}
+		if !reflect.TypeOf(dep).AssignableTo(field.Type) {
+			return fmt.Errorf("%s.%s: dependency '%s' is a %T, not a %s", t.Name(), field.Name, name, dep, field.Type)
+		}
+		v.Field(i).Set(reflect.ValueOf(dep))
+	}
+	return nil
+}
@@ -1,5 +1,10 @@
 package allegory
 
+import (
+	"context"
+	"reflect"
+)
+
 // Initializable is an interface for values that support initialization.
 // This includes game states and actors.
 type Initializable interface {
@@ -65,6 +70,20 @@ type privatelyMessagable interface {
 	handleMessage(msg interface{}) error
 }
 
+// Callable is an interface for processes that can answer requests made
+// with CallProcess(). Call() is run from the process's own goroutine.
+type Callable interface {
+	Call(req interface{}) (interface{}, error)
+}
+
+// TypedMessagable is an interface for processes that only handle certain
+// types of messages. Messages of any other type are logged as a warning
+// instead of being passed to HandleMessage(). A type in the list that's an
+// interface accepts any message that implements it.
+type TypedMessagable interface {
+	AcceptedMessageTypes() []reflect.Type
+}
+
 // Tickable is an interface for processes that need to do something
 // on each frame.
 type Tickable interface {
@@ -76,8 +95,60 @@ type privatelyTickable interface {
 	tick() (bool, error)
 }
 
+// LateTickable is an interface for processes that need a second pass each
+// frame, after every process has finished its Tick(), e.g. to resolve
+// collisions. LateTick() is called once per frame, even if several ticks
+// were run to catch up.
+type LateTickable interface {
+	LateTick() (bool, error)
+}
+
+// TickRateLimited is an interface for processes that should be ticked
+// less often than once per frame. TickRate() returns the maximum number
+// of ticks per second; 0 means no limit.
+type TickRateLimited interface {
+	TickRate() int
+}
+
+// UpdateRateLimited is an interface for actors that should be updated
+// less often than once per frame. UpdateRate() returns the maximum number
+// of updates per second; 0 means no limit.
+type UpdateRateLimited interface {
+	UpdateRate() int
+}
+
+// BatchTickable is an interface for processes that can catch up on several
+// frames at once. When the game loop falls behind and has to run more than
+// one tick in a frame, BatchTick() is called instead of calling Tick()
+// once per tick.
+type BatchTickable interface {
+	BatchTick(n int) (bool, error)
+}
+
+// ContextAware is an interface for processes that need a context that's
+// cancelled once they quit, e.g. to pass along to HTTP requests.
+// SetContext() is called before the process is initialized.
+type ContextAware interface {
+	SetContext(ctx context.Context)
+}
+
 // Continuable is an interface for processes that need to kick off
 // another one when this one finishes.
 type Continuable interface {
 	Next() interface{}
 }
+
+// Branchable is an interface for processes that need to kick off several
+// others when this one finishes, such as a cutscene that starts both the
+// music and the gameplay. If a process implements both Branchable and
+// Continuable, only NextProcesses() is used.
+type Branchable interface {
+	NextProcesses() []interface{}
+}
+
+// PauseExempt is a marker interface for processes and actors that should
+// keep running while the game is paused with PauseAll(), such as the
+// ones that make up a pause menu.
+type PauseExempt interface {
+	PauseExempt()
+}
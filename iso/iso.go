@@ -0,0 +1,89 @@
+// Package iso provides support for isometric (2.5D) rendering.
+//
+// World coordinates are measured in tiles: x runs down and to the right
+// on screen, y runs down and to the left, and z runs straight up.
+package iso
+
+import (
+	"sort"
+)
+
+// IsoTransform converts between world and screen coordinates.
+type IsoTransform struct {
+	// TileWidth and TileHeight are the size in pixels of a single flat
+	// tile's diamond on screen.
+	TileWidth, TileHeight float32
+
+	// OriginX and OriginY are the screen position of world (0, 0, 0).
+	OriginX, OriginY float32
+}
+
+// WorldToScreen() returns the screen position of a point in the world.
+func (t *IsoTransform) WorldToScreen(x, y, z float32) (sx, sy float32) {
+	sx = t.OriginX + (x-y)*t.TileWidth/2
+	sy = t.OriginY + (x+y)*t.TileHeight/2 - z*t.TileHeight
+	return
+}
+
+// ScreenToWorld() returns the point on the ground (z = 0) that is
+// drawn at a given screen position.
+func (t *IsoTransform) ScreenToWorld(sx, sy float32) (x, y float32) {
+	a := (sx - t.OriginX) / (t.TileWidth / 2)
+	b := (sy - t.OriginY) / (t.TileHeight / 2)
+	return (a + b) / 2, (b - a) / 2
+}
+
+// Depth() returns a depth value for a point in the world, suitable for
+// returning from IsoDepth(). Things should be drawn in order of how far
+// down the screen their footprint is, plus their height. In a diamond
+// projection, a footprint's screen y depends on x as well as y (see
+// WorldToScreen()), so the key is x+y+z rather than just y+z; sorting by
+// y+z alone would draw tiles further along the x axis in the wrong order.
+// It doesn't depend on the tile size, so it isn't a method of IsoTransform.
+func Depth(x, y, z float32) float32 {
+	return x + y + z
+}
+
+// IsoDrawable is an interface for actors that can be rendered by an
+// IsoRenderer.
+type IsoDrawable interface {
+	Render(delta float32)
+	IsoDepth() float32
+}
+
+// IsoRenderer is an actor that renders tiles and entities in order of
+// their depth so that nearer things correctly cover farther ones.
+type IsoRenderer struct {
+	items []IsoDrawable
+}
+
+// Add() adds an item to the renderer.
+func (r *IsoRenderer) Add(item IsoDrawable) {
+	r.items = append(r.items, item)
+}
+
+// Remove() removes an item from the renderer.
+func (r *IsoRenderer) Remove(item IsoDrawable) {
+	for i, x := range r.items {
+		if x == item {
+			r.items = append(r.items[:i], r.items[i+1:]...)
+			return
+		}
+	}
+}
+
+// Clear() removes every item from the renderer.
+func (r *IsoRenderer) Clear() {
+	r.items = nil
+}
+
+func (r *IsoRenderer) Render(delta float32) {
+	// depths change as things move, so re-sort every frame; the sort is
+	// stable so that items at the same depth don't flicker
+	sort.SliceStable(r.items, func(i, j int) bool {
+		return r.items[i].IsoDepth() < r.items[j].IsoDepth()
+	})
+	for _, item := range r.items {
+		item.Render(delta)
+	}
+}
@@ -0,0 +1,75 @@
+package iso
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWorldScreenRoundTrip(t *testing.T) {
+	transforms := []IsoTransform{
+		{TileWidth: 64, TileHeight: 32},
+		{TileWidth: 64, TileHeight: 32, OriginX: 400, OriginY: 100},
+		{TileWidth: 30, TileHeight: 17, OriginX: -12.5, OriginY: 8},
+	}
+	points := [][2]float32{{0, 0}, {1, 0}, {0, 1}, {3.5, 2.25}, {-4, 7}}
+	for _, tr := range transforms {
+		for _, p := range points {
+			sx, sy := tr.WorldToScreen(p[0], p[1], 0)
+			x, y := tr.ScreenToWorld(sx, sy)
+			if !near(x, p[0]) || !near(y, p[1]) {
+				t.Errorf("%+v: %v -> (%g, %g) -> (%g, %g)", tr, p, sx, sy, x, y)
+			}
+		}
+	}
+}
+
+func TestHeightRaisesOnScreen(t *testing.T) {
+	tr := IsoTransform{TileWidth: 64, TileHeight: 32}
+	_, ground := tr.WorldToScreen(2, 3, 0)
+	_, raised := tr.WorldToScreen(2, 3, 1)
+	if raised >= ground {
+		t.Errorf("raised point at y=%g isn't above ground point at y=%g", raised, ground)
+	}
+}
+
+type drawable struct {
+	depth float32
+	order *[]float32
+}
+
+func (d *drawable) Render(delta float32) { *d.order = append(*d.order, d.depth) }
+func (d *drawable) IsoDepth() float32    { return d.depth }
+
+func TestRendererDrawsBackToFront(t *testing.T) {
+	var (
+		order []float32
+		r     IsoRenderer
+	)
+	for _, depth := range []float32{3, 1, 2, 5, 4} {
+		r.Add(&drawable{depth, &order})
+	}
+	r.Render(0)
+	for i := 1; i < len(order); i++ {
+		if order[i] < order[i-1] {
+			t.Fatalf("drawn out of order: %v", order)
+		}
+	}
+}
+
+func TestDepthFollowsScreenY(t *testing.T) {
+	tr := IsoTransform{TileWidth: 64, TileHeight: 32}
+	points := [][2]float32{{0, 0}, {3, 0}, {0, 2}, {1, 1}, {4, 1}}
+	for _, a := range points {
+		for _, b := range points {
+			_, ay := tr.WorldToScreen(a[0], a[1], 0)
+			_, by := tr.WorldToScreen(b[0], b[1], 0)
+			if ay < by && Depth(a[0], a[1], 0) >= Depth(b[0], b[1], 0) {
+				t.Errorf("%v is above %v on screen but isn't drawn first", a, b)
+			}
+		}
+	}
+}
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-4
+}
@@ -0,0 +1,151 @@
+package allegory
+
+import (
+	"fmt"
+	"github.com/dradtke/allegory/bus"
+	"reflect"
+	"runtime"
+	"sync/atomic"
+	"weak"
+)
+
+// leakCycles is the number of garbage collections a finished process can
+// survive before it's reported as a leak. A collection doesn't always free
+// everything that's unreachable at the time, so this allows for a little
+// slack.
+const leakCycles = 3
+
+// EnableLeakDetector() starts watching for processes that are never garbage
+// collected after they finish, which usually means something, such as a bus
+// listener, is holding onto them. A leaked process is reported by logging
+// a warning and signaling bus.ProcessLeakEvent with its type name.
+//
+// This works by keeping a weak pointer to each process once it finishes, so
+// processes must be pointers; anything else is ignored. Since the detector
+// can only tell whether a process has been collected, it has some limits:
+//
+//    1. A process that's part of something still in use, such as a global
+//       variable, a field of a struct, e.g. RunProcess(&game.player), or an
+//       element of a slice, is only collected along with the rest of it,
+//       so it's reported if that outlives it.
+//
+//    2. Go can put small values with no pointers in the same block of
+//       memory, which is only freed once all of them are unused, so
+//       processes like that are ignored.
+func EnableLeakDetector() {
+	if atomic.SwapInt32(&_leakDetector, 1) == 0 {
+		_leakSentinelOnce.Do(func() {
+			runtime.SetFinalizer(&gcSentinel{}, onGC)
+		})
+	}
+}
+
+// DisableLeakDetector() stops watching for leaked processes.
+func DisableLeakDetector() {
+	atomic.StoreInt32(&_leakDetector, 0)
+}
+
+// GetLeakCount() returns the number of leaked processes found so far.
+func GetLeakCount() int {
+	return int(atomic.LoadInt32(&_leakCount))
+}
+
+// leakCanary tracks a single finished process.
+type leakCanary struct {
+	typeName string
+	cycles   int // the number of collections the process has survived
+	reported bool
+}
+
+// trackLeak() starts watching a process that has just finished.
+func trackLeak(proc interface{}) {
+	if atomic.LoadInt32(&_leakDetector) == 0 {
+		return
+	}
+	v := reflect.ValueOf(proc)
+	if v.Kind() != reflect.Ptr || v.IsNil() || mayBeBatched(v.Type().Elem()) {
+		return
+	}
+	// the pointer's type doesn't matter, only what it points into
+	w := weak.Make((*byte)(v.UnsafePointer()))
+
+	_leakMutex.Lock()
+	defer _leakMutex.Unlock()
+	if _leakCanaries == nil {
+		_leakCanaries = make(map[weak.Pointer[byte]]*leakCanary)
+	}
+	if c, ok := _leakCanaries[w]; ok {
+		// it's been run again since it was last tracked
+		c.cycles, c.reported = 0, false
+		return
+	}
+	_leakCanaries[w] = &leakCanary{typeName: fmt.Sprintf("%T", proc)}
+}
+
+// mayBeBatched() returns true if values of type t may share their block of
+// memory with other values, i.e. if they're smaller than 16 bytes and
+// contain no pointers, so their process can't be tracked.
+func mayBeBatched(t reflect.Type) bool {
+	return t.Size() < 16 && !hasPointers(t)
+}
+
+// hasPointers() returns true if values of type t contain any pointers.
+func hasPointers(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Array:
+		return t.Len() > 0 && hasPointers(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if hasPointers(t.Field(i).Type) {
+				return true
+			}
+		}
+		return false
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return false
+	}
+	return true
+}
+
+// gcSentinel is an object that's finalized after every garbage collection,
+// since its finalizer sets itself up again on a new one.
+type gcSentinel struct {
+	next *gcSentinel // gives it a pointer so it isn't batched with tiny allocations
+}
+
+func onGC(*gcSentinel) {
+	if atomic.LoadInt32(&_leakDetector) == 1 {
+		checkLeaks()
+	}
+	runtime.SetFinalizer(&gcSentinel{}, onGC)
+}
+
+// checkLeaks() forgets any processes that have been collected, and reports
+// any that have survived too many collections.
+func checkLeaks() {
+	var leaked []string
+	_leakMutex.Lock()
+	for w, c := range _leakCanaries {
+		if w.Value() == nil {
+			delete(_leakCanaries, w)
+			continue
+		}
+		if c.reported {
+			continue
+		}
+		c.cycles++
+		if c.cycles >= leakCycles {
+			c.reported = true
+			leaked = append(leaked, c.typeName)
+		}
+	}
+	_leakMutex.Unlock()
+
+	for _, typeName := range leaked {
+		atomic.AddInt32(&_leakCount, 1)
+		Errorf("process of type %s finished but was never garbage collected", typeName)
+		bus.Signal(bus.ProcessLeakEvent, typeName)
+	}
+}
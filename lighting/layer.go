@@ -0,0 +1,65 @@
+package lighting
+
+import (
+	"github.com/dradtke/go-allegro/allegro"
+	"github.com/dradtke/go-allegro/allegro/primitives"
+)
+
+// LightingLayer is an actor that darkens every tile that isn't lit. It
+// should be added on a layer above the tile map and below the HUD.
+type LightingLayer struct {
+	lights []*Light
+
+	Caster *ShadowCaster
+
+	// TileSize is the size in pixels of a single tile.
+	TileSize float32
+
+	// X and Y offset the layer on screen, e.g. to follow the camera.
+	X, Y float32
+
+	// Ambient is the minimum brightness of every tile, from 0 to 1.
+	Ambient float32
+}
+
+// AddLight() adds a light to the layer.
+func (l *LightingLayer) AddLight(light *Light) {
+	l.lights = append(l.lights, light)
+}
+
+// RemoveLight() removes a light from the layer.
+func (l *LightingLayer) RemoveLight(light *Light) {
+	for i, x := range l.lights {
+		if x == light {
+			l.lights = append(l.lights[:i], l.lights[i+1:]...)
+			return
+		}
+	}
+}
+
+// LightMap() returns the blended light map for every light on the layer.
+func (l *LightingLayer) LightMap() LightMap {
+	maps := make([]LightMap, len(l.lights))
+	for i, light := range l.lights {
+		maps[i] = l.Caster.Illuminate(light)
+	}
+	return Blend(l.Caster.Width, l.Caster.Height, maps...)
+}
+
+func (l *LightingLayer) Render(delta float32) {
+	for y, row := range l.LightMap() {
+		for x, level := range row {
+			if level < l.Ambient {
+				level = l.Ambient
+			}
+			if level >= 1 {
+				continue
+			}
+			px, py := l.X+float32(x)*l.TileSize, l.Y+float32(y)*l.TileSize
+			primitives.DrawFilledRectangle(
+				primitives.Point{X: px, Y: py},
+				primitives.Point{X: px + l.TileSize, Y: py + l.TileSize},
+				allegro.MapRGBAf(0, 0, 0, 1-level))
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Package lighting provides support for tile-based 2D lighting.
+package lighting
+
+import (
+	"math"
+)
+
+// LightMap holds how brightly lit each tile is, from 0 (dark) to 1,
+// indexed as LightMap[y][x].
+type LightMap [][]float32
+
+// NewLightMap() creates a completely dark light map.
+func NewLightMap(width, height int) LightMap {
+	m := make(LightMap, height)
+	for y := range m {
+		m[y] = make([]float32, width)
+	}
+	return m
+}
+
+// Blend() combines several light maps into one by adding them together,
+// capping each tile at full brightness.
+func Blend(width, height int, maps ...LightMap) LightMap {
+	result := NewLightMap(width, height)
+	for _, m := range maps {
+		for y := 0; y < height && y < len(m); y++ {
+			for x := 0; x < width && x < len(m[y]); x++ {
+				result[y][x] = float32(math.Min(1, float64(result[y][x]+m[y][x])))
+			}
+		}
+	}
+	return result
+}
+
+// Light is a point light source positioned on a tile.
+type Light struct {
+	X, Y   int
+	Radius int
+
+	// Intensity is the brightness at the light's own tile, from 0 to 1.
+	Intensity float32
+
+	cached                    LightMap
+	cachedX, cachedY, cachedR int
+	cachedI                   float32
+	cachedVersion             int
+}
+
+// ShadowCaster computes which tiles a light can reach using recursive
+// shadowcasting, so walls cast proper shadows.
+type ShadowCaster struct {
+	Width, Height int
+
+	// Opaque reports whether the tile at (x, y) blocks light.
+	Opaque func(x, y int) bool
+
+	version int
+}
+
+// Invalidate() tells the caster that the tile map has changed, which
+// forces every light to be recalculated.
+func (s *ShadowCaster) Invalidate() {
+	s.version++
+}
+
+// Illuminate() returns the light map for a single light. The result is
+// cached, and is only recalculated when the light moves or changes, or
+// when the caster has been invalidated.
+func (s *ShadowCaster) Illuminate(light *Light) LightMap {
+	if light.cached != nil && light.cachedVersion == s.version &&
+		light.cachedX == light.X && light.cachedY == light.Y &&
+		light.cachedR == light.Radius && light.cachedI == light.Intensity {
+		return light.cached
+	}
+	light.cached = s.Compute(light.X, light.Y, light.Radius, light.Intensity)
+	light.cachedX, light.cachedY = light.X, light.Y
+	light.cachedR, light.cachedI = light.Radius, light.Intensity
+	light.cachedVersion = s.version
+	return light.cached
+}
+
+// octants holds the transforms that map the single octant handled
+// by castLight() onto each of the eight octants around the light.
+var octants = [8][4]int{
+	{1, 0, 0, 1}, {0, 1, 1, 0}, {0, -1, 1, 0}, {-1, 0, 0, 1},
+	{-1, 0, 0, -1}, {0, -1, -1, 0}, {0, 1, -1, 0}, {1, 0, 0, -1},
+}
+
+// Compute() calculates a new light map for a light at (x, y), ignoring the cache.
+// Brightness falls off linearly with distance from the light.
+func (s *ShadowCaster) Compute(x, y, radius int, intensity float32) LightMap {
+	m := NewLightMap(s.Width, s.Height)
+	if !s.inBounds(x, y) {
+		return m
+	}
+	m[y][x] = intensity
+	for _, o := range octants {
+		s.castLight(m, x, y, 1, 1.0, 0.0, radius, intensity, o[0], o[1], o[2], o[3])
+	}
+	return m
+}
+
+// castLight() lights one octant, row by row, recursing whenever
+// an opaque tile splits the visible area in two.
+func (s *ShadowCaster) castLight(m LightMap, cx, cy, row int, start, end float64, radius int, intensity float32, xx, xy, yx, yy int) {
+	if start < end {
+		return
+	}
+	radiusSq := radius * radius
+	var newStart float64
+	for j := row; j <= radius; j++ {
+		dx, dy := -j-1, -j
+		blocked := false
+		for dx <= 0 {
+			dx++
+			x, y := cx+dx*xx+dy*xy, cy+dx*yx+dy*yy
+			leftSlope := (float64(dx) - 0.5) / (float64(dy) + 0.5)
+			rightSlope := (float64(dx) + 0.5) / (float64(dy) - 0.5)
+			if start < rightSlope {
+				continue
+			} else if end > leftSlope {
+				break
+			}
+
+			if distSq := dx*dx + dy*dy; distSq < radiusSq && s.inBounds(x, y) {
+				level := intensity * (1 - float32(math.Sqrt(float64(distSq)))/float32(radius))
+				if level > m[y][x] {
+					m[y][x] = level
+				}
+			}
+
+			if blocked {
+				if s.opaque(x, y) {
+					newStart = rightSlope
+					continue
+				}
+				blocked = false
+				start = newStart
+			} else if s.opaque(x, y) && j < radius {
+				blocked = true
+				s.castLight(m, cx, cy, j+1, start, leftSlope, radius, intensity, xx, xy, yx, yy)
+				newStart = rightSlope
+			}
+		}
+		if blocked {
+			break
+		}
+	}
+}
+
+func (s *ShadowCaster) inBounds(x, y int) bool {
+	return x >= 0 && x < s.Width && y >= 0 && y < s.Height
+}
+
+// opaque() treats everything off the edge of the map as a wall.
+func (s *ShadowCaster) opaque(x, y int) bool {
+	return !s.inBounds(x, y) || (s.Opaque != nil && s.Opaque(x, y))
+}
@@ -0,0 +1,201 @@
+package allegory
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLockstepTimeout is how long to wait for a peer's input before
+// assuming they've disconnected, unless SetLockstepTimeout() is called.
+const DefaultLockstepTimeout = 5 * time.Second
+
+// EnableLockstep() switches the game loop into lockstep mode, in which
+// every step waits until each peer has sent its input for that step.
+// All clients therefore advance together, one frame at a time.
+func EnableLockstep(m *LockstepManager) {
+	_lockstep.Store(m)
+}
+
+// DisableLockstep() switches the game loop back to normal.
+func DisableLockstep() {
+	_lockstep.Store((*LockstepManager)(nil))
+}
+
+// LockstepEnabled() returns true if the game loop is in lockstep mode.
+func LockstepEnabled() bool {
+	return lockstepManager() != nil
+}
+
+// lockstepManager() returns the manager set by EnableLockstep(), or nil if
+// the game loop isn't in lockstep mode.
+func lockstepManager() *LockstepManager {
+	m, _ := _lockstep.Load().(*LockstepManager)
+	return m
+}
+
+// SetLockstepTimeout() sets how long to wait for a peer's input before
+// dropping them.
+func SetLockstepTimeout(t time.Duration) {
+	atomic.StoreInt64(&_lockstepTimeout, int64(t))
+}
+
+// LockstepManager exchanges per-frame inputs with a set of peers.
+// Each input is sent as a frame number and a length, both big-endian
+// uint32's, followed by the input itself.
+type LockstepManager struct {
+	mutex   sync.Mutex
+	localID string
+	local   []byte
+	frame   int
+	peers   map[string]*lockstepPeer
+	notify  chan struct{}
+}
+
+type lockstepPeer struct {
+	rw     io.ReadWriter
+	inputs map[int][]byte
+	err    error
+}
+
+// NewLockstepManager() creates a lockstep manager. localID is the key
+// that this client's own input is returned under by WaitForAllInputs().
+func NewLockstepManager(localID string) *LockstepManager {
+	return &LockstepManager{
+		localID: localID,
+		peers:   make(map[string]*lockstepPeer),
+		notify:  make(chan struct{}, 1),
+	}
+}
+
+// RegisterPeer() adds a peer, reading its inputs from ch in the background.
+func (m *LockstepManager) RegisterPeer(id string, ch io.ReadWriter) {
+	peer := &lockstepPeer{rw: ch, inputs: make(map[int][]byte)}
+	m.mutex.Lock()
+	m.peers[id] = peer
+	m.mutex.Unlock()
+	go m.read(peer)
+}
+
+// Frame() returns the number of the frame currently being waited on.
+func (m *LockstepManager) Frame() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.frame
+}
+
+// BroadcastInput() sends this client's input for the current frame to every peer.
+func (m *LockstepManager) BroadcastInput(input []byte) {
+	m.mutex.Lock()
+	m.local = input
+	frame := m.frame
+	peers := make([]*lockstepPeer, 0, len(m.peers))
+	for _, peer := range m.peers {
+		peers = append(peers, peer)
+	}
+	m.mutex.Unlock()
+
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(frame))
+	binary.BigEndian.PutUint32(header[4:], uint32(len(input)))
+	for _, peer := range peers {
+		if _, err := peer.rw.Write(append(header[:], input...)); err != nil {
+			m.fail(peer, err)
+		}
+	}
+}
+
+// WaitForAllInputs() blocks until every peer has sent its input for the
+// current frame, then moves on to the next frame. It returns the number of
+// the frame that was completed and the inputs keyed by peer id, including
+// this client's own. Peers that time out or whose connection fails are
+// dropped.
+func (m *LockstepManager) WaitForAllInputs() (int, map[string][]byte) {
+	timeout := time.Duration(atomic.LoadInt64(&_lockstepTimeout))
+	if timeout <= 0 {
+		timeout = DefaultLockstepTimeout
+	}
+	deadline := time.After(timeout)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for timedOut := false; !timedOut && !m.ready(); {
+		m.mutex.Unlock()
+		select {
+		case <-m.notify:
+		case <-deadline:
+			timedOut = true
+		}
+		m.mutex.Lock()
+	}
+
+	frame := m.frame
+	inputs := map[string][]byte{m.localID: m.local}
+	for id, peer := range m.peers {
+		input, ok := peer.inputs[frame]
+		if !ok {
+			if peer.err != nil {
+				Errorf("lockstep peer %s disconnected: %s", id, peer.err)
+			} else {
+				Errorf("lockstep peer %s timed out on frame %d", id, frame)
+			}
+			delete(m.peers, id)
+			continue
+		}
+		inputs[id] = input
+		delete(peer.inputs, frame)
+	}
+	m.local = nil
+	m.frame++
+	return frame, inputs
+}
+
+// ready() returns true once every live peer has sent input for the current frame.
+func (m *LockstepManager) ready() bool {
+	for _, peer := range m.peers {
+		if _, ok := peer.inputs[m.frame]; !ok && peer.err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// read() receives inputs from a peer until its connection fails.
+func (m *LockstepManager) read(peer *lockstepPeer) {
+	var header [8]byte
+	for {
+		if _, err := io.ReadFull(peer.rw, header[:]); err != nil {
+			m.fail(peer, err)
+			return
+		}
+		frame := int(binary.BigEndian.Uint32(header[:4]))
+		input := make([]byte, binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.ReadFull(peer.rw, input); err != nil {
+			m.fail(peer, err)
+			return
+		}
+		m.mutex.Lock()
+		peer.inputs[frame] = input
+		m.mutex.Unlock()
+		m.wake()
+	}
+}
+
+func (m *LockstepManager) fail(peer *lockstepPeer, err error) {
+	m.mutex.Lock()
+	if peer.err == nil {
+		peer.err = err
+	}
+	m.mutex.Unlock()
+	m.wake()
+}
+
+// wake() lets WaitForAllInputs() know that something has changed.
+func (m *LockstepManager) wake() {
+	select {
+	case m.notify <- struct{}{}:
+	default:
+	}
+}
@@ -3,6 +3,7 @@ package allegory
 import (
 	"fmt"
 	"github.com/synful/term"
+	"io"
 	"os"
 )
 
@@ -23,7 +24,9 @@ func Infof(format string, v ...interface{}) {
 }
 
 func Error(value interface{}) {
-	term.Red(os.Stderr, "[ERROR] "+toString(value)+"\n")
+	_errorWriterMutex.Lock()
+	defer _errorWriterMutex.Unlock()
+	term.Red(errorWriter(), "[ERROR] "+toString(value)+"\n")
 }
 
 func Errorf(format string, v ...interface{}) {
@@ -42,3 +45,30 @@ func toString(value interface{}) string {
 		return fmt.Sprintf("%v", v)
 	}
 }
+
+// SetErrorWriter() sets where the engine writes its errors, which is
+// os.Stderr by default. This includes everything written by Error() and
+// Errorf(). The bus package has its own error writer, which is set
+// separately with bus.SetErrorWriter().
+func SetErrorWriter(w io.Writer) {
+	_errorWriter.Store(errorWriterBox{w})
+}
+
+// errorWriterBox lets _errorWriter hold writers of different types.
+type errorWriterBox struct {
+	w io.Writer
+}
+
+func errorWriter() io.Writer {
+	if box, ok := _errorWriter.Load().(errorWriterBox); ok && box.w != nil {
+		return box.w
+	}
+	return os.Stderr
+}
+
+// printErrorf() writes a formatted error to the error writer.
+func printErrorf(format string, v ...interface{}) {
+	_errorWriterMutex.Lock()
+	defer _errorWriterMutex.Unlock()
+	fmt.Fprintf(errorWriter(), format, v...)
+}
@@ -3,6 +3,7 @@ package allegory
 import (
 	"errors"
 	"fmt"
+	"github.com/dradtke/allegory/bus"
 	"github.com/dradtke/allegory/config"
 	"github.com/dradtke/go-allegro/allegro"
 	"os"
@@ -29,7 +30,7 @@ func loopExiting() {
 		failure = fmt.Errorf("%v", r)
 	}
 
-	fmt.Fprintf(os.Stderr, "%s\n", failure.Error())
+	printErrorf("%s\n", failure.Error())
 	cwd, err := os.Getwd()
 	if err != nil {
 		panic(err)
@@ -39,7 +40,7 @@ func loopExiting() {
 	for {
 		if _, file, line, ok := runtime.Caller(skip); ok && filepath.Ext(file) == ".go" {
 			if rel, err := filepath.Rel(cwd, file); err == nil {
-				fmt.Fprintf(os.Stderr, "    %s:%d\n", rel, line)
+				printErrorf("    %s:%d\n", rel, line)
 			} else {
 				break
 			}
@@ -101,62 +102,30 @@ func loop() {
 			elapsed = now.Sub(lastUpdate)
 			lastUpdate = now
 			lag += elapsed
-			for lag >= step {
-				NotifyAllProcesses(&tick{})
-				for _, actor := range _state.Actors() {
-					var updated bool
-					if state, ok := _actorStates[actor]; ok {
-						if state, ok := state.(UpdateableStatefully); ok {
-							if newState := state.Update(); newState != nil {
-								SetActorState(actor, newState)
-							}
-							updated = true
-						} else if state, ok := state.(Updateable); ok {
-							state.Update()
-							updated = true
-						}
-					}
-					if !updated {
-						if actor, ok := actor.(Updateable); ok {
-							actor.Update()
-						}
-					}
-				}
-				_state.Update()
+			steps := 0
+			for lag >= step && steps < _maxCatchUpTicks {
 				lag -= step
+				steps++
+			}
+			if lag >= step {
+				// too far behind to catch up, so drop the rest
+				lag %= step
+			}
+			endFrame := profile("Frame")
+			if steps > 0 {
+				endUpdate := profile("Update")
+				update(steps)
+				endUpdate()
 			}
+			evaluateSignals()
 
+			endRender := profile("Render")
 			allegro.ClearToColor(config.BlankColor())
-
-			// Render
-
-			delta := float32(lag / step)
-			_state.Render(delta)
-
-			//allegro.HoldBitmapDrawing(true) // ???: why does this kill it?
-			actorLayers := _state.ActorLayers()
-			for i := uint(0); i <= _highestLayer; i++ {
-				layer, ok := actorLayers[i]
-				if !ok {
-					continue
-				}
-				for _, actor := range layer {
-					var rendered bool
-					if state, ok := _actorStates[actor]; ok {
-						if state, ok := state.(Renderable); ok {
-							state.Render(delta)
-							rendered = true
-						}
-					}
-					if !rendered {
-						if actor, ok := actor.(Renderable); ok {
-							actor.Render(delta)
-						}
-					}
-				}
-			}
-			//allegro.HoldBitmapDrawing(false)
+			render(float32(lag / step))
 			allegro.FlipDisplay()
+			endRender()
+			endFrame()
+			profileFrame()
 
 			ticking = false
 		}
@@ -165,8 +134,83 @@ func loop() {
 	allegro.ClearToColor(config.BlankColor())
 	allegro.FlipDisplay()
 
-	// Tell all processes to quit immediately, then wait
-	// for them to finish before exiting.
+	shutdown()
+}
+
+// update() runs n fixed steps of game logic: processes are ticked n times,
+// then actors and the current state are updated n times. Any state change
+// queued up by a process is applied first.
+//
+// In lockstep mode, each step waits for every peer's input first, then
+// signals bus.LockstepInputEvent with the inputs before advancing.
+func update(n int) {
+	applyPendingState()
+	if m := lockstepManager(); m != nil {
+		for i := 0; i < n; i++ {
+			frame, inputs := m.WaitForAllInputs()
+			bus.Signal(bus.LockstepInputEvent, frame, inputs)
+			advance(1)
+		}
+		return
+	}
+	advance(n)
+}
+
+// advance() runs n steps of updates without waiting on anything, then
+// updates any bindings.
+func advance(n int) {
+	if Paused() {
+		tickProcesses(isPauseExempt, n)
+	} else {
+		tickProcesses(nil, n)
+	}
+	for i := 0; i < n; i++ {
+		updateActorsAndState()
+	}
+	updateBindings()
+}
+
+// updateActorsAndState() runs a single step of updates for the current
+// state and each of its actors.
+func updateActorsAndState() {
+	var (
+		now    = time.Now()
+		paused = Paused()
+	)
+	for _, actor := range _state.Actors() {
+		if paused && !isPauseExempt(actor) && !isPauseExempt(_actorStates[actor]) {
+			continue
+		}
+		if limited, ok := actor.(UpdateRateLimited); ok && limited.UpdateRate() > 0 {
+			if now.Sub(_actorLastUpdate[actor]) < time.Second/time.Duration(limited.UpdateRate()) {
+				continue
+			}
+			_actorLastUpdate[actor] = now
+		}
+		var updated bool
+		if state, ok := _actorStates[actor]; ok {
+			if s, ok := state.(UpdateableStatefully); ok {
+				if newState := s.Update(); newState != nil {
+					SetActorState(actor, newState)
+				}
+				updated = true
+			} else if s, ok := state.(Updateable); ok {
+				s.Update()
+				updated = true
+			}
+		}
+		if !updated {
+			if actor, ok := actor.(Updateable); ok {
+				actor.Update()
+			}
+		}
+	}
+	_state.Update()
+}
+
+// shutdown() tells all processes to quit immediately, then waits
+// for them to finish before exiting.
+func shutdown() {
 	for !_state.Empty() {
 		cur := _state.Current()
 		if cur == nil {
@@ -181,3 +225,32 @@ func loop() {
 		}
 	}
 }
+
+// render() draws the current state, followed by each layer of actors.
+func render(delta float32) {
+	_state.Render(delta)
+
+	//allegro.HoldBitmapDrawing(true) // ???: why does this kill it?
+	actorLayers := _state.ActorLayers()
+	for i := uint(0); i <= _highestLayer; i++ {
+		layer, ok := actorLayers[i]
+		if !ok {
+			continue
+		}
+		for _, actor := range layer {
+			var rendered bool
+			if state, ok := _actorStates[actor]; ok {
+				if state, ok := state.(Renderable); ok {
+					state.Render(delta)
+					rendered = true
+				}
+			}
+			if !rendered {
+				if actor, ok := actor.(Renderable); ok {
+					actor.Render(delta)
+				}
+			}
+		}
+	}
+	//allegro.HoldBitmapDrawing(false)
+}
@@ -0,0 +1,34 @@
+package allegory
+
+// ProcessMiddleware wraps the handling of every message sent to every
+// process, other than the engine's own ticks and quits. It's given the
+// process, the message, and a function that passes a message on to the
+// next middleware, or to the process's HandleMessage() once there are no
+// more. Middleware can inspect or log the message, replace it with another
+// one, or drop it by not calling next at all. Any error returned makes the
+// process exit, just as if HandleMessage() had returned it.
+type ProcessMiddleware func(proc interface{}, msg interface{}, next func(interface{}) error) error
+
+// AddProcessMiddleware() adds middleware to the end of the chain that
+// messages pass through, so middleware runs in the order it's added.
+func AddProcessMiddleware(mw ProcessMiddleware) {
+	_middlewareMutex.Lock()
+	defer _middlewareMutex.Unlock()
+	old, _ := _processMiddleware.Load().([]ProcessMiddleware)
+	chain := make([]ProcessMiddleware, len(old), len(old)+1)
+	copy(chain, old)
+	_processMiddleware.Store(append(chain, mw))
+}
+
+// withMiddleware() wraps a process's message handler in the middleware
+// chain.
+func withMiddleware(proc interface{}, handle func(interface{}) error) func(interface{}) error {
+	chain, _ := _processMiddleware.Load().([]ProcessMiddleware)
+	for i := len(chain) - 1; i >= 0; i-- {
+		mw, next := chain[i], handle
+		handle = func(msg interface{}) error {
+			return mw(proc, msg, next)
+		}
+	}
+	return handle
+}
@@ -0,0 +1,67 @@
+package allegory
+
+import (
+	"fmt"
+)
+
+// MigrateProcess() moves a running process from one state to another, so
+// that from then on it's treated as if it had been started in the new
+// state: it's ticked whenever that state is current, and messages sent to
+// that state's processes reach it.
+//
+// If the new state isn't on the stack yet, the process is held until it's
+// pushed, which makes it possible to hand processes over during a
+// transition:
+//
+//    MigrateProcess(music, "title", "game")
+//    NewState("game")
+//
+// While it's being held, the process isn't ticked.
+func MigrateProcess(proc interface{}, fromState, toState StateID) error {
+	from, ok := _stateMap[fromState]
+	if !ok {
+		return fmt.Errorf("can't migrate from invalid state '%s'", fromState)
+	}
+	to, ok := _stateMap[toState]
+	if !ok {
+		return fmt.Errorf("can't migrate to invalid state '%s'", toState)
+	}
+
+	_processMutex.Lock()
+	defer _processMutex.Unlock()
+	stats, ok := _processStats[proc]
+	if !ok || stats.state != from || !removeProcess(from, proc) {
+		return fmt.Errorf("process isn't running in state '%s'", fromState)
+	}
+	if _state.Contains(to) {
+		insertProcess(to, proc)
+	} else {
+		stats.state = nil
+		_migrations[to] = append(_migrations[to], proc)
+	}
+	return nil
+}
+
+// adoptMigratedProcesses() adds any processes waiting to be migrated to
+// a state that's just been pushed.
+func adoptMigratedProcesses(state *gameState) {
+	_processMutex.Lock()
+	defer _processMutex.Unlock()
+	for _, proc := range _migrations[state] {
+		insertProcess(state, proc)
+	}
+	delete(_migrations, state)
+}
+
+// forgetMigration() drops a process that's waiting to be migrated. The
+// caller must hold _processMutex.
+func forgetMigration(proc interface{}) {
+	for state, procs := range _migrations {
+		for i, p := range procs {
+			if p == proc {
+				_migrations[state] = append(procs[:i], procs[i+1:]...)
+				break
+			}
+		}
+	}
+}
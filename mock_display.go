@@ -0,0 +1,21 @@
+//go:build allegorytest
+
+package allegory
+
+// UseMockDisplay() makes the engine run without a display, so that tests
+// can run on CI servers that don't have a display server. Run() behaves
+// like RunHeadless(), and Fatal() logs its error instead of showing a
+// message box. It should be called from TestMain(), and is only available
+// when building with the allegorytest tag:
+//
+//	go test -tags allegorytest ./...
+func UseMockDisplay() {
+	_mockDisplay = true
+}
+
+// UseMockAudio() is the audio counterpart of UseMockDisplay(). The engine
+// doesn't install Allegro's audio addons itself, so there's nothing for it
+// to replace yet and it does nothing, but tests can call it from TestMain()
+// so that they keep working once the engine plays audio.
+func UseMockAudio() {
+}
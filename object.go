@@ -0,0 +1,160 @@
+package allegory
+
+// GameObject is a single thing in the game, such as an enemy, made up of
+// the processes that drive it and the views that draw it. Game objects
+// are created with NewObject().
+type GameObject struct {
+	Name      string
+	ID        EntityID
+	Processes []interface{}
+	Views     []interface{}
+	Tags      []string
+	Team      int
+	HasTeam   bool
+}
+
+// ObjectBuilder declares a game object piece by piece:
+//
+//	enemy, err := allegory.NewObject("enemy").
+//		WithProcess(&EnemyProcess{}).
+//		WithView(&EnemyView{}).
+//		WithTag("hostile").
+//		WithTeam(1).
+//		Build()
+type ObjectBuilder struct {
+	obj   GameObject
+	hasID bool
+	layer uint
+	views []objectView
+}
+
+type objectView struct {
+	view  interface{}
+	layer uint
+}
+
+// NewObject() starts building a game object.
+func NewObject(name string) *ObjectBuilder {
+	return &ObjectBuilder{obj: GameObject{Name: name}, layer: 1}
+}
+
+// WithID() sets the object's entity ID. If it isn't set, a local one is
+// generated from a range the server never assigns (see EntityID), so it
+// should always be set for objects that are shared over the network.
+func (b *ObjectBuilder) WithID(id EntityID) *ObjectBuilder {
+	b.obj.ID, b.hasID = id, true
+	return b
+}
+
+// WithProcess() adds a process to run for the object.
+func (b *ObjectBuilder) WithProcess(proc interface{}) *ObjectBuilder {
+	b.obj.Processes = append(b.obj.Processes, proc)
+	return b
+}
+
+// WithLayer() sets the layer that views added after it are drawn on. The
+// default is layer 1.
+func (b *ObjectBuilder) WithLayer(layer uint) *ObjectBuilder {
+	b.layer = layer
+	return b
+}
+
+// WithView() adds an actor to draw the object.
+func (b *ObjectBuilder) WithView(view interface{}) *ObjectBuilder {
+	b.views = append(b.views, objectView{view, b.layer})
+	return b
+}
+
+// WithTag() tags the object so that it can be found with FindTagged().
+func (b *ObjectBuilder) WithTag(tag string) *ObjectBuilder {
+	b.obj.Tags = append(b.obj.Tags, tag)
+	return b
+}
+
+// WithTeam() puts the object on a team registered with the global team
+// manager.
+func (b *ObjectBuilder) WithTeam(teamID int) *ObjectBuilder {
+	b.obj.Team, b.obj.HasTeam = teamID, true
+	return b
+}
+
+// Build() creates the object. Its team and tags are set up first, so that
+// they can be relied on as soon as anything starts; then its processes are
+// run; then its views are added to the current state.
+func (b *ObjectBuilder) Build() (*GameObject, error) {
+	obj := new(GameObject)
+	*obj = b.obj
+	if !b.hasID {
+		obj.ID = newLocalEntityID()
+	}
+
+	if obj.HasTeam {
+		if err := Teams().AddToTeam(obj.ID, obj.Team, obj.Processes...); err != nil {
+			return nil, err
+		}
+	}
+
+	_taggedMutex.Lock()
+	if _tagged == nil {
+		_tagged = make(map[string][]*GameObject)
+	}
+	for _, tag := range obj.Tags {
+		_tagged[tag] = append(_tagged[tag], obj)
+	}
+	_taggedMutex.Unlock()
+
+	for _, proc := range obj.Processes {
+		RunProcess(proc)
+	}
+	for _, v := range b.views {
+		AddActor(v.layer, v.view, nil)
+		obj.Views = append(obj.Views, v.view)
+	}
+	return obj, nil
+}
+
+// HasTag() returns true if the object has the given tag.
+func (o *GameObject) HasTag(tag string) bool {
+	for _, t := range o.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Destroy() undoes Build(): the object's views are destroyed, its
+// processes are closed, and its tags and team are removed.
+func (o *GameObject) Destroy() {
+	for _, view := range o.Views {
+		DestroyActor(view)
+	}
+	for _, proc := range o.Processes {
+		Close(proc)
+	}
+
+	_taggedMutex.Lock()
+	for _, tag := range o.Tags {
+		objs := _tagged[tag]
+		for i, obj := range objs {
+			if obj == o {
+				_tagged[tag] = append(objs[:i], objs[i+1:]...)
+				break
+			}
+		}
+	}
+	_taggedMutex.Unlock()
+
+	if o.HasTeam {
+		Teams().UnassignTeam(o.ID)
+	}
+}
+
+// FindTagged() returns every game object with the given tag.
+func FindTagged(tag string) []*GameObject {
+	_taggedMutex.Lock()
+	defer _taggedMutex.Unlock()
+	objs := make([]*GameObject, len(_tagged[tag]))
+	copy(objs, _tagged[tag])
+	return objs
+}
@@ -0,0 +1,30 @@
+package allegory
+
+import (
+	"sync/atomic"
+)
+
+// PauseAll() freezes the game. Until ResumeAll() is called, processes
+// stop receiving ticks and actors stop being updated, unless they
+// implement PauseExempt. The current state is still updated so that it
+// can respond to input, and everything is still rendered, so the last
+// frame stays visible behind any pause overlay.
+func PauseAll() {
+	atomic.StoreInt32(&_paused, 1)
+}
+
+// ResumeAll() unfreezes a game paused with PauseAll().
+func ResumeAll() {
+	atomic.StoreInt32(&_paused, 0)
+}
+
+// Paused() returns true if the game is currently paused with PauseAll().
+func Paused() bool {
+	return atomic.LoadInt32(&_paused) == 1
+}
+
+// isPauseExempt() returns true if v should keep running while the game is paused.
+func isPauseExempt(v interface{}) bool {
+	_, ok := v.(PauseExempt)
+	return ok
+}
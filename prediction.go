@@ -0,0 +1,120 @@
+package allegory
+
+import (
+	"errors"
+	"sync"
+)
+
+// DefaultPredictionFrames is how many frames of history a PredictionLayer
+// keeps if a non-positive size is passed to NewPredictionLayer().
+const DefaultPredictionFrames = 60
+
+// PlayerInput is a single player's input for one frame.
+type PlayerInput struct {
+	Player string
+	Data   []byte
+}
+
+// Predictable is the interface a game simulation needs to implement in
+// order to be driven by a PredictionLayer.
+type Predictable interface {
+	// SaveState() returns a snapshot of the current simulation state.
+	SaveState() []byte
+
+	// LoadState() replaces the simulation state with a snapshot.
+	LoadState(state []byte)
+
+	// Simulate() advances the simulation by one frame.
+	Simulate(input PlayerInput)
+}
+
+// PredictionLayer applies local input immediately instead of waiting on
+// the server, then corrects itself when the server's canonical state
+// arrives by rewinding to that frame and replaying the inputs since.
+// The state at the start of each frame, and the input applied during
+// it, are kept in a circular buffer.
+type PredictionLayer struct {
+	mutex  sync.Mutex
+	sim    Predictable
+	frame  int
+	states [][]byte
+	inputs []PlayerInput
+}
+
+// NewPredictionLayer() creates a prediction layer around sim that can roll
+// back at most size frames.
+func NewPredictionLayer(sim Predictable, size int) *PredictionLayer {
+	if size <= 0 {
+		size = DefaultPredictionFrames
+	}
+	return &PredictionLayer{
+		sim:    sim,
+		states: make([][]byte, size),
+		inputs: make([]PlayerInput, size),
+	}
+}
+
+// Frame() returns the number of the frame the next input will be applied to.
+func (p *PredictionLayer) Frame() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.frame
+}
+
+// ApplyInput() records input for the current frame and simulates it right
+// away.
+func (p *PredictionLayer) ApplyInput(input PlayerInput) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	i := p.frame % len(p.states)
+	p.states[i] = p.sim.SaveState()
+	p.inputs[i] = input
+	p.sim.Simulate(input)
+	p.frame++
+}
+
+// ReceiveServerState() accepts the server's state as of the start of frame.
+// If that frame has already been predicted, the layer rolls back to it and
+// replays its inputs on top of the new state; if it hasn't been reached yet,
+// the layer jumps ahead to it. States older than the history are ignored.
+func (p *PredictionLayer) ReceiveServerState(frame int, state []byte) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if frame >= p.frame {
+		p.sim.LoadState(state)
+		p.frame = frame
+		return
+	}
+	if !p.hasFrame(frame) {
+		return
+	}
+	p.states[frame%len(p.states)] = state
+	p.rollback(frame)
+}
+
+// Rollback() rewinds the simulation to the start of toFrame and replays
+// every input recorded since, leaving it on the same frame as before.
+func (p *PredictionLayer) Rollback(toFrame int) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !p.hasFrame(toFrame) {
+		return errors.New("frame not in prediction history")
+	}
+	p.rollback(toFrame)
+	return nil
+}
+
+func (p *PredictionLayer) hasFrame(frame int) bool {
+	return frame >= 0 && frame < p.frame && frame >= p.frame-len(p.states)
+}
+
+func (p *PredictionLayer) rollback(toFrame int) {
+	p.sim.LoadState(p.states[toFrame%len(p.states)])
+	for f := toFrame; f < p.frame; f++ {
+		i := f % len(p.states)
+		if f > toFrame {
+			p.states[i] = p.sim.SaveState()
+		}
+		p.sim.Simulate(p.inputs[i])
+	}
+}
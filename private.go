@@ -2,7 +2,11 @@ package allegory
 
 import (
 	"github.com/dradtke/go-allegro/allegro"
+	"net/url"
 	"sync"
+	"sync/atomic"
+	"time"
+	"weak"
 )
 
 var (
@@ -18,15 +22,82 @@ var (
 	_actorLayers map[*gameState]map[uint][]interface{}
 	_actorStates map[interface{}]interface{}
 
+	_actorLastUpdate map[interface{}]time.Time // when each rate-limited actor was last updated
+
+	_processStats map[interface{}]*ProcessStats // statistics for each running process
+	_processDone  map[interface{}]chan struct{} // closed when each running process finishes
+	_migrations   map[*gameState][]interface{}  // processes waiting for a state to be pushed
+	_children     map[interface{}][]interface{} // child processes by parent
+	_processNames map[string]interface{}        // running processes by name
+	_frameBudget  int64                         // the time limit for ticking processes each frame, as a time.Duration
+
+	_maxCatchUpTicks = 5  // the maximum number of ticks to run in a single frame
+	_maxChainDepth   = 64 // the maximum number of successors in a row
+
+	_lockstep        atomic.Value // holds the *LockstepManager when the game loop is in lockstep mode
+	_lockstepTimeout int64        // how long to wait for lockstep peers, as a time.Duration
+
 	_messengers map[interface{}]chan interface{} // an internal map from process to message channel
 	_atexit     []func()
 
+	_signals []reactive // every signal, in the order they were created
+
+	_urlHandlers map[string]func(*url.URL) // URL handlers by scheme
+
+	_sharingBackend SharingBackend // where ShareScreenshot() uploads to
+	_sharingCopyURL bool           // copy shared screenshot URLs to the clipboard?
+
+	_entityFactories map[string]func(map[string]interface{}) interface{} // entity factories by type name
+
+	_teams     *TeamManager // the global team manager
+	_teamsOnce sync.Once
+
+	_tagged       map[string][]*GameObject // game objects by tag
+	_nextObjectID uint32                   // the last generated local object ID
+
+	_profiler *flameRecorder // set while a flame graph is being recorded
+
+	_mockDisplay bool // set by UseMockDisplay() in test builds
+
+	_leakDetector     int32                              // set to 1 by EnableLeakDetector()
+	_leakCount        int32                              // the number of leaked processes found
+	_leakCanaries     map[weak.Pointer[byte]]*leakCanary // finished processes
+	_leakSentinelOnce sync.Once
+
+	_tickProfiling int32                        // set to 1 by EnableTickProfiling()
+	_tickBudget    int64                        // the tick budget, as a time.Duration
+	_tickProfiles  map[interface{}]*tickHistory // recent tick durations by process
+
+	_messageSchemas    atomic.Value // a map[reflect.Type][]FieldSpec, replaced on every registration
+	_processMiddleware atomic.Value // a []ProcessMiddleware, replaced whenever one is added
+	_errorWriter       atomic.Value // where errors are written, if not os.Stderr
+
+	_bindings []*Binding // every model-view binding, updated once per frame
+
+	_sticky []interface{} // the latest sticky message of each type, oldest first
+
+	_pendingState *pendingState // a state change waiting for the game loop
+
 	_actorsMutex  sync.Mutex
-	_processMutex sync.Mutex // a mutex used to protect _processes
+	_processMutex sync.Mutex // a mutex used to protect _processes and _messengers
+	_signalsMutex sync.Mutex // a mutex used to protect _signals
+
+	_entityFactoriesMutex sync.Mutex // a mutex used to protect _entityFactories
+	_taggedMutex          sync.Mutex // a mutex used to protect _tagged
+	_profilerMutex        sync.Mutex // a mutex used to protect _profiler
+	_leakMutex            sync.Mutex // a mutex used to protect _leakCanaries
+	_messageSchemasMutex  sync.Mutex // a mutex used to serialize updates to _messageSchemas
+	_bindingsMutex        sync.Mutex // a mutex used to protect _bindings
+	_middlewareMutex      sync.Mutex // a mutex used to serialize updates to _processMiddleware
+	_tickProfilesMutex    sync.Mutex // a mutex used to protect _tickProfiles
+	_stickyMutex          sync.Mutex // a mutex used to protect _sticky
+	_errorWriterMutex     sync.Mutex // a mutex used to serialize writes to the error writer
+	_pendingStateMutex    sync.Mutex // a mutex used to protect _pendingState
 
 	_event        allegro.Event
 	_pressedKeys  map[allegro.KeyCode]bool
 	_highestLayer uint
+	_paused       int32               // set to 1 by PauseAll() and cleared by ResumeAll()
 	_stdin        = make(chan string) // channel of data read from stdin
 )
 
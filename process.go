@@ -1,37 +1,480 @@
 package allegory
 
 import (
+	"context"
 	"fmt"
-	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// NotifyProcess() sends an arbitrary message to a process.
-func NotifyProcess(proc interface{}, msg interface{}) {
+// NotifyProcess() sends an arbitrary message to a process. If a schema has
+// been registered for the message's type and the message doesn't match it,
+// it isn't sent and an error is returned instead.
+func NotifyProcess(proc interface{}, msg interface{}) error {
+	if err := validateMessage(msg); err != nil {
+		return err
+	}
+	notifyProcess(proc, msg)
+	return nil
+}
+
+// notifyProcess() sends a message to a process, returning true if
+// the process received it.
+func notifyProcess(proc interface{}, msg interface{}) (sent bool) {
 	defer func() {
 		// don't let closed channels kill the program
-		recover()
+		if recover() != nil {
+			sent = false
+		}
 	}()
-	if ch, ok := _messengers[proc]; ok {
-		ch <- msg
+	_processMutex.Lock()
+	ch, ok := _messengers[proc]
+	_processMutex.Unlock()
+	if !ok {
+		return false
 	}
+	ch <- msg
+	return true
 }
 
 // NotifyAllProcesses() sends an arbitrary message to all running
-// processes.
-func NotifyAllProcesses(msg interface{}) {
-	for _, process := range _processes[_state.Current()] {
-		NotifyProcess(process, msg)
+// processes. Like NotifyProcess(), it returns an error without sending
+// anything if the message doesn't match its schema.
+func NotifyAllProcesses(msg interface{}) error {
+	if err := validateMessage(msg); err != nil {
+		return err
+	}
+	for _, process := range currentProcesses() {
+		notifyProcess(process, msg)
 	}
+	return nil
 }
 
 // NotifyWhere() sends an arbitrary message to each running process
-// that matches the filter criteria.
-func NotifyWhere(msg interface{}, filter func(interface{}) bool) {
-	for _, process := range _processes[_state.Current()] {
+// that matches the filter criteria. Like NotifyProcess(), it returns an
+// error without sending anything if the message doesn't match its schema.
+func NotifyWhere(msg interface{}, filter func(interface{}) bool) error {
+	if err := validateMessage(msg); err != nil {
+		return err
+	}
+	for _, process := range currentProcesses() {
 		if filter(process) {
-			NotifyProcess(process, msg)
+			notifyProcess(process, msg)
+		}
+	}
+	return nil
+}
+
+// Request is a message that expects a reply. The process handling it
+// should send its answer on Reply, which may be buffered or not depending
+// on the sender.
+type Request struct {
+	Payload interface{}
+	Reply   chan<- interface{}
+}
+
+// NotifyRequest() sends msg to a process wrapped in a *Request, so that
+// the process can send something back on reply, e.g.
+//
+//    reply := make(chan interface{}, 1)
+//    NotifyRequest(inventory, &CountItems{"potion"}, reply)
+//    count := (<-reply).(int)
+//
+// Schemas are checked against msg itself.
+func NotifyRequest(proc interface{}, msg interface{}, reply chan<- interface{}) error {
+	if err := validateMessage(msg); err != nil {
+		return err
+	}
+	notifyProcess(proc, &Request{msg, reply})
+	return nil
+}
+
+// UnwrapRequest() returns the payload and reply channel of a message sent
+// with NotifyRequest(), or false if msg isn't a request.
+func UnwrapRequest(msg interface{}) (payload interface{}, reply chan<- interface{}, ok bool) {
+	req, ok := msg.(*Request)
+	if !ok || req == nil {
+		return nil, nil, false
+	}
+	return req.Payload, req.Reply, true
+}
+
+// NotifySticky() sends a message to all running processes like
+// NotifyAllProcesses(), but also remembers it, so that every process started
+// afterwards receives it too before anything else. Only the most recent
+// sticky message of each type is kept, until it's cleared with
+// ClearSticky().
+func NotifySticky(msg interface{}) error {
+	if err := NotifyAllProcesses(msg); err != nil {
+		return err
+	}
+	_stickyMutex.Lock()
+	defer _stickyMutex.Unlock()
+	removeSticky(reflect.TypeOf(msg))
+	_sticky = append(_sticky, msg)
+	return nil
+}
+
+// ClearSticky() forgets the sticky message of the given type, if there is
+// one, so that new processes no longer receive it.
+func ClearSticky(msgType reflect.Type) {
+	_stickyMutex.Lock()
+	defer _stickyMutex.Unlock()
+	removeSticky(msgType)
+}
+
+// removeSticky() removes the sticky message of a type. The caller must hold
+// _stickyMutex.
+func removeSticky(msgType reflect.Type) {
+	for i, msg := range _sticky {
+		if reflect.TypeOf(msg) == msgType {
+			_sticky = append(_sticky[:i], _sticky[i+1:]...)
+			return
+		}
+	}
+}
+
+// stickyMessages() returns the current sticky messages, oldest first.
+func stickyMessages() []interface{} {
+	_stickyMutex.Lock()
+	defer _stickyMutex.Unlock()
+	return append([]interface{}(nil), _sticky...)
+}
+
+// RunNamedProcess() runs a process like RunProcess(), but also registers it
+// under a name so that it can be found with FindProcess(). Names must be
+// unique; if the name is already taken, a warning is logged and a numeric
+// suffix is added to it. The name actually used is returned, and is
+// released once the process finishes.
+func RunNamedProcess(name string, proc interface{}, opts ...ProcessOption) string {
+	_processMutex.Lock()
+	unique := name
+	for i := 2; _processNames[unique] != nil; i++ {
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	if unique != name {
+		Errorf("process name '%s' is already taken; using '%s' instead", name, unique)
+	}
+	_processNames[unique] = proc
+	_processMutex.Unlock()
+
+	RunProcess(proc, opts...)
+	return unique
+}
+
+// FindProcess() returns the running process registered under a name, or
+// nil if there isn't one.
+func FindProcess(name string) interface{} {
+	_processMutex.Lock()
+	defer _processMutex.Unlock()
+	return _processNames[name]
+}
+
+// IsProcessRunning() returns true if a process is running under a name.
+func IsProcessRunning(name string) bool {
+	return FindProcess(name) != nil
+}
+
+// SetMaxChainDepth() limits how many successors can be started one after
+// another via Continuable or Branchable, so that processes that keep
+// handing off to each other don't run forever by mistake. Once the limit
+// is reached, an error is logged and the chain ends. The default is 64; a
+// limit of 0 or less removes it.
+func SetMaxChainDepth(n int) {
+	_maxChainDepth = n
+}
+
+// checkChain() returns an error if next can't be started as the successor
+// of the processes in chain, either because it's already one of them or
+// because the chain is too long.
+func checkChain(next interface{}, chain []interface{}) error {
+	if _maxChainDepth > 0 && len(chain) >= _maxChainDepth {
+		return fmt.Errorf("process chain is longer than %d", _maxChainDepth)
+	}
+	for _, p := range chain {
+		if p == next {
+			return fmt.Errorf("process chain has a cycle at %T", next)
+		}
+	}
+	return nil
+}
+
+// insertProcess() adds a process to a state's process list, after any
+// processes with the same or higher priority. The caller must hold
+// _processMutex, and the process must already have stats.
+func insertProcess(state *gameState, proc interface{}) {
+	stats := _processStats[proc]
+	stats.state = state
+	procs := _processes[state]
+	i := sort.Search(len(procs), func(i int) bool {
+		other := _processStats[procs[i]]
+		return other != nil && other.priority < stats.priority
+	})
+	procs = append(procs, nil)
+	copy(procs[i+1:], procs[i:])
+	procs[i] = proc
+	_processes[state] = procs
+}
+
+// removeProcess() removes a process from a state's process list, returning
+// false if it wasn't there. The caller must hold _processMutex.
+func removeProcess(state *gameState, proc interface{}) bool {
+	procs := _processes[state]
+	for i, process := range procs {
+		if process == proc {
+			// clear out the last slot so that the backing array
+			// doesn't keep the process from being collected
+			copy(procs[i:], procs[i+1:])
+			procs[len(procs)-1] = nil
+			_processes[state] = procs[:len(procs)-1]
+			return true
+		}
+	}
+	return false
+}
+
+// currentProcesses() returns a copy of the list of processes running in
+// the current state, which is safe to range over while processes start and
+// stop.
+func currentProcesses() []interface{} {
+	_processMutex.Lock()
+	defer _processMutex.Unlock()
+	return append([]interface{}(nil), _processes[_state.Current()]...)
+}
+
+// isRunning() returns true if proc is currently running.
+func isRunning(proc interface{}) bool {
+	_processMutex.Lock()
+	defer _processMutex.Unlock()
+	_, ok := _messengers[proc]
+	return ok
+}
+
+// processName() returns the name a process was registered under, or a
+// description of it if it doesn't have one.
+func processName(proc interface{}) string {
+	_processMutex.Lock()
+	defer _processMutex.Unlock()
+	for name, p := range _processNames {
+		if p == proc {
+			return fmt.Sprintf("process '%s'", name)
 		}
 	}
+	return fmt.Sprintf("process %T", proc)
+}
+
+// acceptsMessage() returns true if proc accepts messages of msg's type,
+// which is always the case unless it implements TypedMessagable.
+func acceptsMessage(proc interface{}, msg interface{}) bool {
+	typed, ok := proc.(TypedMessagable)
+	if !ok {
+		return true
+	}
+	t := reflect.TypeOf(msg)
+	for _, accepted := range typed.AcceptedMessageTypes() {
+		if t == accepted || (t != nil && accepted.Kind() == reflect.Interface && t.Implements(accepted)) {
+			return true
+		}
+	}
+	return false
+}
+
+// forgetProcessName() releases the name of a process, if it has one. The
+// caller must hold _processMutex.
+func forgetProcessName(proc interface{}) {
+	for name, p := range _processNames {
+		if p == proc {
+			delete(_processNames, name)
+		}
+	}
+}
+
+// SetFrameBudget() limits how much time can be spent ticking processes
+// each frame. Once the budget is used up, the remaining processes are
+// deferred to the next frame, where they get to go first. Setting
+// a budget means that each tick is waited on before the next process is
+// ticked, so processes no longer tick in parallel. A budget of 0, the
+// default, disables the limit.
+func SetFrameBudget(budget time.Duration) {
+	atomic.StoreInt64(&_frameBudget, int64(budget))
+}
+
+// ProcessInfo describes a running process.
+type ProcessInfo struct {
+	Type    string      // the name of the process's type
+	Started time.Time   // when the process was started
+	Ticks   int         // the number of ticks it's been sent so far
+	Process interface{} // the process itself
+}
+
+// ListProcesses() returns information about every process running in the
+// current state, in the order they're ticked. It's safe to call from any
+// goroutine.
+func ListProcesses() []ProcessInfo {
+	_processMutex.Lock()
+	defer _processMutex.Unlock()
+	procs := _processes[_state.Current()]
+	infos := make([]ProcessInfo, 0, len(procs))
+	for _, proc := range procs {
+		info := ProcessInfo{Type: reflect.TypeOf(proc).String(), Process: proc}
+		if stats, ok := _processStats[proc]; ok {
+			info.Started, info.Ticks = stats.started, stats.ticks
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// StatsFor() returns statistics about a running process.
+func StatsFor(proc interface{}) ProcessStats {
+	_processMutex.Lock()
+	defer _processMutex.Unlock()
+	if stats, ok := _processStats[proc]; ok {
+		return *stats
+	}
+	return ProcessStats{}
+}
+
+// SetMaxCatchUpTicks() limits how many ticks can be run in a single frame
+// when the game loop falls behind, e.g. after a long load. Any time beyond
+// that is dropped. The default is 5, and the minimum is 1.
+func SetMaxCatchUpTicks(n int) {
+	if n < 1 {
+		n = 1
+	}
+	_maxCatchUpTicks = n
+}
+
+// tickProcesses() sends n ticks to each process in the current state that
+// matches the filter, or to all of them if filter is nil.
+func tickProcesses(filter func(interface{}) bool, n int) {
+	var (
+		now    = time.Now()
+		counts = make(map[interface{}]int)
+	)
+	_processMutex.Lock()
+	procs := make([]interface{}, 0, len(_processes[_state.Current()]))
+	for _, proc := range _processes[_state.Current()] {
+		if filter != nil && !filter(proc) {
+			continue
+		}
+		stats := _processStats[proc]
+		if stats != nil && stats.paused {
+			continue
+		}
+		count := n
+		var interval time.Duration
+		if stats != nil {
+			interval = stats.tickInterval
+		}
+		if limited, ok := proc.(TickRateLimited); ok && interval == 0 && limited.TickRate() > 0 {
+			interval = time.Second / time.Duration(limited.TickRate())
+		}
+		if interval > 0 {
+			// rate-limited processes get at most one tick per frame, and only
+			// if enough time has passed since their last one
+			if stats == nil || now.Sub(stats.lastTick) < interval {
+				continue
+			}
+			stats.lastTick = now
+			count = 1
+		}
+		if stats != nil {
+			stats.ticks += count
+		}
+		procs = append(procs, proc)
+		counts[proc] = count
+	}
+	_processMutex.Unlock()
+
+	budget := time.Duration(atomic.LoadInt64(&_frameBudget))
+	if budget <= 0 {
+		if !anyLateTickable(procs) {
+			for _, proc := range procs {
+				NotifyProcess(proc, &tick{count: counts[proc]})
+			}
+			return
+		}
+		// the late ticks can't start until every tick has finished
+		ticks := make([]*tick, 0, len(procs))
+		for _, proc := range procs {
+			t := &tick{count: counts[proc], done: make(chan struct{})}
+			if notifyProcess(proc, t) {
+				ticks = append(ticks, t)
+			}
+		}
+		for _, t := range ticks {
+			<-t.done
+		}
+		lateTickProcesses(procs)
+		return
+	}
+
+	// anything that was deferred last frame goes first
+	_processMutex.Lock()
+	sort.SliceStable(procs, func(i, j int) bool {
+		a, b := _processStats[procs[i]], _processStats[procs[j]]
+		return a != nil && a.deferred && (b == nil || !b.deferred)
+	})
+	_processMutex.Unlock()
+
+	start := time.Now()
+	for i, proc := range procs {
+		if time.Since(start) >= budget {
+			// deferred processes miss their late tick too
+			lateTickProcesses(procs[:i])
+			_processMutex.Lock()
+			for _, proc := range procs[i:] {
+				if stats, ok := _processStats[proc]; ok {
+					stats.DeferredFrames++
+					stats.deferred = true
+				}
+			}
+			_processMutex.Unlock()
+			return
+		}
+		t := &tick{count: counts[proc], done: make(chan struct{})}
+		if notifyProcess(proc, t) {
+			<-t.done
+		}
+		_processMutex.Lock()
+		if stats, ok := _processStats[proc]; ok {
+			stats.deferred = false
+		}
+		_processMutex.Unlock()
+	}
+	lateTickProcesses(procs)
+}
+
+// anyLateTickable() returns true if any of procs implements LateTickable.
+func anyLateTickable(procs []interface{}) bool {
+	for _, proc := range procs {
+		if _, ok := proc.(LateTickable); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// lateTickProcesses() calls LateTick() on each of procs that implements
+// it, and waits for them all to finish.
+func lateTickProcesses(procs []interface{}) {
+	var ticks []*lateTick
+	for _, proc := range procs {
+		if _, ok := proc.(LateTickable); !ok {
+			continue
+		}
+		t := &lateTick{done: make(chan struct{})}
+		if notifyProcess(proc, t) {
+			ticks = append(ticks, t)
+		}
+	}
+	for _, t := range ticks {
+		<-t.done
+	}
 }
 
 // Close() sends a Quit message to a process.
@@ -39,6 +482,19 @@ func Close(proc interface{}) {
 	NotifyProcess(proc, &quit{})
 }
 
+// SoftClose() asks a process to quit once it's done with whatever it's
+// currently handling, instead of queueing a Quit message behind the ones
+// it hasn't gotten to yet, which are dropped. The process is still
+// cleaned up, but its successor isn't started.
+func SoftClose(proc interface{}) {
+	_processMutex.Lock()
+	defer _processMutex.Unlock()
+	if stats, ok := _processStats[proc]; ok && !stats.stopping {
+		stats.stopping = true
+		close(stats.stop)
+	}
+}
+
 // RunProcess() takes a Process and kicks it off in a new
 // goroutine. That goroutine continually listens for messages
 // on its internal channel and dispatches them to the defined
@@ -50,103 +506,625 @@ func Close(proc interface{}) {
 //    2. Tick messages, which simply tell the process to
 //       process one frame.
 //
-func RunProcess(proc interface{}) {
+// Options such as WithContext() can be passed to change how the process
+// is run. The returned handle can be used to control the process later.
+func RunProcess(proc interface{}, opts ...ProcessOption) *ProcessHandle {
+	return RunProcessWithPriority(proc, 0, opts...)
+}
+
+// RunProcessWithPriority() is like RunProcess(), but lets you control the
+// order in which processes receive their ticks: higher priorities are
+// ticked first, and processes with the same priority are ticked in the
+// order they were started. RunProcess() uses a priority of 0. A process's
+// successor inherits its priority and options.
+//
+// Unless a frame budget has been set with SetFrameBudget(), processes tick
+// in parallel, so the priority only determines who's sent a tick first.
+func RunProcessWithPriority(proc interface{}, priority int, opts ...ProcessOption) *ProcessHandle {
+	options := processOptions{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	ctx, cancel := context.WithCancel(options.ctx)
+	if p, ok := proc.(ContextAware); ok {
+		p.SetContext(ctx)
+	}
+
 	var initFn func() error = nil
 
-	if proc, ok := proc.(privatelyInitializableWithFailure); ok {
-		initFn = proc.init
-	} else if proc, ok := proc.(InitializableWithFailure); ok {
-		initFn = proc.Init
+	if p, ok := proc.(privatelyInitializableWithFailure); ok {
+		initFn = p.init
+	} else if p, ok := proc.(InitializableWithFailure); ok {
+		initFn = p.Init
 	}
 
-	if initFn != nil {
-		if err := initFn(); err != nil {
-			fmt.Fprintf(os.Stderr, "error during process initialization: %s\n", err.Error())
-			return
+	err := InjectDependencies(proc)
+	if err == nil && initFn != nil && !options.warmStart {
+		end := profileProcess(proc, "Init")
+		err = initFn()
+		end()
+	}
+	if err != nil {
+		printErrorf("error during process initialization: %s\n", err.Error())
+		cancel()
+		_processMutex.Lock()
+		forgetProcessName(proc)
+		_processMutex.Unlock()
+		if options.onExit != nil {
+			options.onExit(err, false)
 		}
+		done := make(chan struct{})
+		close(done)
+		return &ProcessHandle{proc: proc, done: done}
 	}
 
-    cur := _state.Current()
-	ch := make(chan interface{})
-	_messengers[proc] = ch
+	cur := _state.Current()
+	ch := make(chan interface{}, options.capacity)
 	_processMutex.Lock()
-	_processes[cur] = append(_processes[cur], proc)
+	_messengers[proc] = ch
+	stop := make(chan struct{})
+	_processStats[proc] = &ProcessStats{
+		priority:     priority,
+		tickInterval: options.tickInterval,
+		stop:         stop,
+		started:      time.Now(),
+	}
+	insertProcess(cur, proc)
+	done := make(chan struct{})
+	_processDone[proc] = done
 	_processMutex.Unlock()
+	pause := new(processPause)
+	handle := &ProcessHandle{proc: proc, ch: ch, done: done, pause: pause}
+
+	go func() {
+		var (
+			alive   bool          = true // is the process running?
+			carryOn bool          = true // should the process kick off its successor, if any?
+			err     error         = nil
+			held    []interface{} = nil  // messages received while paused, to be handled next
+		)
 
-	go func(cur *gameState) {
 		defer func() {
 			_processMutex.Lock()
-			for i, process := range _processes[cur] {
-				if process == proc {
-					_processes[cur] = append(_processes[cur][:i], _processes[cur][i+1:]...)
-					break
-				}
+			// the process may have been migrated to another state
+			if stats := _processStats[proc]; stats != nil && stats.state != nil {
+				removeProcess(stats.state, proc)
 			}
-			_processMutex.Unlock()
+			forgetMigration(proc)
+			children := takeChildren(proc)
+			delete(_processStats, proc)
+			delete(_processDone, proc)
+			forgetProcessName(proc)
 			delete(_messengers, proc)
+			_processMutex.Unlock()
+			for _, child := range children {
+				Close(child)
+			}
 			close(ch)
+			// release anyone still waiting on a buffered tick
+			for msg := range ch {
+				switch m := msg.(type) {
+				case *tick:
+					if m.done != nil {
+						close(m.done)
+					}
+				case *lateTick:
+					close(m.done)
+				}
+			}
+			close(done)
+			trackLeak(proc)
+			forgetTickProfile(proc)
+			if options.onExit != nil {
+				options.onExit(err, true)
+			}
 		}()
 
-		var (
-			alive   bool  = true // is the process running?
-			carryOn bool  = true // should the process kick off its successor, if any?
-			err     error = nil
-		)
+		var handleMessageFn func(msg interface{}) error = nil
+
+		if p, ok := proc.(privatelyMessagable); ok {
+			handleMessageFn = p.handleMessage
+		} else if p, ok := proc.(Messagable); ok {
+			handleMessageFn = p.HandleMessage
+		}
+
+		handle := func(msg interface{}) error {
+			if handleMessageFn == nil {
+				return nil
+			}
+			if !acceptsMessage(proc, msg) {
+				Errorf("%s doesn't accept messages of type %T; ignoring", processName(proc), msg)
+				return nil
+			}
+			end := profileProcess(proc, "HandleMessage")
+			defer end()
+			return handleMessageFn(msg)
+		}
+
+		// dispatch() passes a message through any middleware to the
+		// process's handler
+		dispatch := func(msg interface{}) error {
+			return withMiddleware(proc, handle)(msg)
+		}
+
+		for _, msg := range stickyMessages() {
+			if err = dispatch(msg); err != nil {
+				alive = false
+				carryOn = false
+				printErrorf("Process handled %v with error message '%s'\n", msg, err.Error())
+				break
+			}
+		}
 
 		for alive {
-			switch msg := <-ch; msg.(type) {
+			select {
+			case <-stop:
+				// soft-closed; the last message has been fully handled
+				alive = false
+				carryOn = false
+				continue
+			default:
+			}
+
+			var msg interface{}
+			if len(held) > 0 {
+				msg, held = held[0], held[1:]
+			} else {
+				select {
+				case msg = <-ch:
+				case <-ctx.Done():
+					// a finished context is treated the same as a quit message
+					msg = &quit{}
+				case <-stop:
+					continue
+				}
+			}
+
+			switch m := msg.(type) {
 			case *quit:
 				alive = false
 				carryOn = false
+				if options.drain {
+					drainMessages(proc, ch, options.drainTimeout, dispatch)
+				}
 
 			case *tick:
+				more, resumed := pause.wait(m, ch, ctx, stop)
+				held = append(held, more...)
+				if !resumed {
+					continue
+				}
+
 				var tickFn func() (bool, error) = nil
 
-				if proc, ok := proc.(privatelyTickable); ok {
-					tickFn = proc.tick
-				} else if proc, ok := proc.(Tickable); ok {
-					tickFn = proc.Tick
+				if p, ok := proc.(privatelyTickable); ok {
+					tickFn = p.tick
+				} else if p, ok := proc.(Tickable); ok {
+					tickFn = p.Tick
+				}
+				if tickFn != nil {
+					tickFn = timeTicks(proc, tickFn)
+				}
+
+				if proc, ok := proc.(BatchTickable); ok && m.count > 1 {
+					tickFn = func() (bool, error) { return proc.BatchTick(m.count) }
+				} else if single := tickFn; single != nil && m.count > 1 {
+					tickFn = func() (bool, error) {
+						for i := 0; i < m.count; i++ {
+							if alive, err := single(); !alive || err != nil {
+								return alive, err
+							}
+						}
+						return true, nil
+					}
 				}
 
 				if tickFn != nil {
-					if alive, err = tickFn(); err != nil {
+					end := profileProcess(proc, "Tick")
+					alive, err = tickFn()
+					end()
+					if err != nil {
 						alive = false
 						carryOn = false
-						fmt.Fprintf(os.Stderr, "Process exited with error message '%s'\n", err.Error())
+						printErrorf("Process exited with error message '%s'\n", err.Error())
 					}
 				}
-
-			default:
-				var handleMessageFn func(msg interface{}) error = nil
-
-				if proc, ok := proc.(privatelyMessagable); ok {
-					handleMessageFn = proc.handleMessage
-				} else if proc, ok := proc.(Messagable); ok {
-					handleMessageFn = proc.HandleMessage
+				if m.done != nil {
+					close(m.done)
 				}
 
-				if handleMessageFn != nil {
-					if err := handleMessageFn(msg); err != nil {
+			case *call:
+				m.answer(proc)
+
+			case *lateTick:
+				if p, ok := proc.(LateTickable); ok {
+					end := profileProcess(proc, "LateTick")
+					alive, err = p.LateTick()
+					end()
+					if err != nil {
 						alive = false
 						carryOn = false
-						fmt.Fprintf(os.Stderr, "Process handled %v with error message '%s'\n", err.Error())
+						printErrorf("Process exited with error message '%s'\n", err.Error())
 					}
 				}
+				close(m.done)
+
+			default:
+				err = dispatch(msg)
+				if err != nil {
+					alive = false
+					carryOn = false
+					printErrorf("Process handled %v with error message '%s'\n", msg, err.Error())
+				}
 			}
 		}
 
-		if proc, ok := proc.(Cleanupable); ok {
-			proc.Cleanup()
+		cancel()
+
+		if p, ok := proc.(Cleanupable); ok {
+			end := profileProcess(proc, "Cleanup")
+			p.Cleanup()
+			end()
 		}
 
-		if proc, ok := proc.(Continuable); carryOn && ok {
-			if next := proc.Next(); next != nil {
-				RunProcess(next)
+		if carryOn {
+			// exit handlers belong to this process, not its successors
+			chain := append(append([]interface{}{}, options.chain...), proc)
+			successorOpts := append([]ProcessOption{}, opts...)
+			successorOpts = append(successorOpts, func(o *processOptions) {
+				o.onExit = nil
+				o.warmStart = false
+				o.chain = chain
+			})
+			var successors []interface{}
+			if p, ok := proc.(Branchable); ok {
+				successors = p.NextProcesses()
+			} else if p, ok := proc.(Continuable); ok {
+				successors = []interface{}{p.Next()}
 			}
+			for _, next := range successors {
+				if next == nil {
+					continue
+				}
+				if err := checkChain(next, chain); err != nil {
+					printErrorf("not starting successor: %s\n", err.Error())
+					continue
+				}
+				RunProcessWithPriority(next, priority, successorOpts...)
+			}
+		}
+	}()
+
+	return handle
+}
+
+// ProcessHandle controls a process started by RunProcess().
+type ProcessHandle struct {
+	proc  interface{}
+	ch    chan interface{}
+	done  chan struct{}
+	pause *processPause // nil if the process never started
+}
+
+// Process() returns the process itself.
+func (h *ProcessHandle) Process() interface{} {
+	return h.proc
+}
+
+// Send() sends an arbitrary message to the process, returning false if it
+// has already finished.
+func (h *ProcessHandle) Send(msg interface{}) (sent bool) {
+	defer func() {
+		// don't let closed channels kill the program
+		if recover() != nil {
+			sent = false
 		}
-	}(cur)
+	}()
+	if h.ch == nil {
+		return false
+	}
+	select {
+	case h.ch <- msg:
+		return true
+	case <-h.done:
+		return false
+	}
+}
+
+// Close() sends a Quit message to the process.
+func (h *ProcessHandle) Close() {
+	h.Send(&quit{})
 }
 
-type tick struct{}
+// SoftClose() soft-closes the process. See SoftClose().
+func (h *ProcessHandle) SoftClose() {
+	SoftClose(h.proc)
+}
+
+// Pause() makes the process's goroutine block before its next tick until
+// Resume() is called. The game loop doesn't wait for a paused process:
+// ticks sent to it while it's blocked are dropped, and any other messages
+// are handled once it's resumed, except for quit messages, which still
+// stop it.
+func (h *ProcessHandle) Pause() {
+	if h.pause != nil {
+		h.pause.set(true)
+	}
+}
+
+// Resume() unblocks a process paused with Pause().
+func (h *ProcessHandle) Resume() {
+	if h.pause != nil {
+		h.pause.set(false)
+	}
+}
+
+// Done() returns a channel that's closed once the process has finished.
+func (h *ProcessHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+// processPause blocks a process's goroutine while it's paused by its handle.
+type processPause struct {
+	mutex   sync.Mutex
+	paused  bool
+	resumed chan struct{} // closed when the process is resumed
+}
+
+// set() pauses or resumes the process.
+func (p *processPause) set(paused bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if paused == p.paused {
+		return
+	}
+	p.paused = paused
+	if paused {
+		p.resumed = make(chan struct{})
+	} else {
+		close(p.resumed)
+	}
+}
+
+// wait() blocks before running t while the process is paused, returning
+// true once it's resumed, or false if it's told to quit, soft-closed or its
+// context finishes first. The game loop is let go of t straight away, ticks
+// received in the meantime are dropped, and other messages, including the
+// quit message, are returned to be handled next.
+func (p *processPause) wait(t *tick, ch <-chan interface{}, ctx context.Context, stop <-chan struct{}) (held []interface{}, resumed bool) {
+	p.mutex.Lock()
+	paused, resumedCh := p.paused, p.resumed
+	p.mutex.Unlock()
+	if !paused {
+		return nil, true
+	}
+	if t.done != nil {
+		close(t.done)
+		t.done = nil
+	}
+	for {
+		select {
+		case <-resumedCh:
+			return held, true
+		case <-ctx.Done():
+			return held, false
+		case <-stop:
+			return held, false
+		case msg := <-ch:
+			switch m := msg.(type) {
+			case *tick:
+				if m.done != nil {
+					close(m.done)
+				}
+			case *lateTick:
+				close(m.done)
+			case *quit:
+				return append(held, msg), false
+			default:
+				held = append(held, msg)
+			}
+		}
+	}
+}
+
+// setProcessPaused() sets whether a running process should be skipped
+// when ticking.
+func setProcessPaused(proc interface{}, paused bool) {
+	_processMutex.Lock()
+	defer _processMutex.Unlock()
+	if stats, ok := _processStats[proc]; ok {
+		stats.paused = paused
+	}
+}
+
+// WaitForProcess() returns a channel that's closed once a process has
+// finished, after it's been cleaned up and its successor, if any, has been
+// started. If the process isn't running, the channel is already closed.
+func WaitForProcess(proc interface{}) <-chan struct{} {
+	_processMutex.Lock()
+	defer _processMutex.Unlock()
+	if done, ok := _processDone[proc]; ok {
+		return done
+	}
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+
+// LivenessChannel() is the same as WaitForProcess(), for use in selects
+// that react to processes exiting rather than wait on them:
+//
+//    select {
+//    case <-LivenessChannel(enemy):
+//        ...
+//    case <-LivenessChannel(player):
+//        ...
+//    }
+func LivenessChannel(proc interface{}) <-chan struct{} {
+	return WaitForProcess(proc)
+}
+
+// WaitForProcessTimeout() blocks until a process has finished or the
+// timeout runs out, returning false if it timed out.
+func WaitForProcessTimeout(proc interface{}, timeout time.Duration) bool {
+	select {
+	case <-WaitForProcess(proc):
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// WaitForAllProcesses() blocks until no processes are running in any
+// state, or the timeout runs out, returning false if it timed out.
+func WaitForAllProcesses(timeout time.Duration) bool {
+	deadline := time.After(timeout)
+	for {
+		_processMutex.Lock()
+		var done <-chan struct{}
+		for _, ch := range _processDone {
+			done = ch
+			break
+		}
+		_processMutex.Unlock()
+		if done == nil {
+			return true
+		}
+		select {
+		case <-done:
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+// ProcessOption configures how RunProcess() runs a process.
+type ProcessOption func(*processOptions)
+
+type processOptions struct {
+	ctx       context.Context   // the context the process's own context is derived from
+	onExit    func(error, bool) // called once the process has finished, with its error and whether Init() succeeded
+	capacity  int               // the size of the process's message buffer
+	chain     []interface{}     // the processes that this one succeeded, oldest first
+	warmStart bool              // skip Init(), since the process is being restarted warm
+
+	tickInterval time.Duration // the minimum time between ticks, if rate-limited
+	drain        bool          // handle the remaining messages after quitting?
+	drainTimeout time.Duration // the longest to spend draining, if positive
+}
+
+// WithContext() ties a process to a context. Once the context is done, the
+// process quits just as if Close() had been called on it, so it still gets
+// cleaned up but its successor isn't started.
+func WithContext(ctx context.Context) ProcessOption {
+	return func(o *processOptions) {
+		o.ctx = ctx
+	}
+}
+
+// WithChannelCapacity() gives a process a message buffer of size n, so that
+// NotifyProcess() doesn't block until the process gets around to reading the
+// message as long as there's room. Messages are still handled in the order
+// they were sent. By default, process channels are unbuffered.
+func WithChannelCapacity(n int) ProcessOption {
+	return func(o *processOptions) {
+		if n >= 0 {
+			o.capacity = n
+		}
+	}
+}
+
+// WithTickRate() limits a process to at most hz ticks per second, no matter
+// how fast the game loop is running, just like implementing TickRateLimited
+// would. It takes precedence over the process's own TickRate(), if it has
+// one. A rate of 0 or less removes the limit.
+func WithTickRate(hz float64) ProcessOption {
+	return func(o *processOptions) {
+		if hz > 0 {
+			o.tickInterval = time.Duration(float64(time.Second) / hz)
+		} else {
+			o.tickInterval = 0
+		}
+	}
+}
+
+// WithDrainOnClose() makes a process handle any messages still waiting to
+// be delivered once it's told to quit, whether by Close() or its context,
+// instead of dropping them, before it's cleaned up. Ticks are ignored
+// while draining. Draining stops once there are no more messages, once
+// timeout has passed if it's positive, or if handling one of them fails.
+func WithDrainOnClose(timeout time.Duration) ProcessOption {
+	return func(o *processOptions) {
+		o.drain = true
+		o.drainTimeout = timeout
+	}
+}
+
+// drainMessages() dispatches whatever messages are left in a closed
+// process's channel.
+func drainMessages(proc interface{}, ch chan interface{}, timeout time.Duration, dispatch func(interface{}) error) {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		deadline = time.After(timeout)
+	}
+	for {
+		var msg interface{}
+		select {
+		case msg = <-ch:
+		case <-deadline:
+			return
+		default:
+			return
+		}
+		switch m := msg.(type) {
+		case *quit:
+		case *tick:
+			if m.done != nil {
+				close(m.done)
+			}
+		case *lateTick:
+			close(m.done)
+		case *call:
+			m.answer(proc)
+		default:
+			if err := dispatch(msg); err != nil {
+				printErrorf("Process handled %v with error message '%s' while draining\n", msg, err.Error())
+				return
+			}
+		}
+	}
+}
+
+// ProcessStats holds statistics about a running process.
+type ProcessStats struct {
+	// DeferredFrames is the number of frames in which the process wasn't
+	// ticked because the frame budget ran out.
+	DeferredFrames int
+
+	deferred bool      // was the process deferred last frame?
+	lastTick time.Time // when the process was last ticked, if it's rate-limited
+	priority int       // the priority the process was started with
+	paused   bool      // is the process paused by its handle or group?
+
+	tickInterval time.Duration // the minimum time between ticks, set by WithTickRate()
+
+	stop     chan struct{} // closed by SoftClose()
+	stopping bool          // has stop been closed?
+
+	state   *gameState // the state the process belongs to
+	started time.Time  // when the process was started
+	ticks   int        // the number of ticks the process has been sent
+}
+
+type tick struct {
+	count int           // the number of ticks to process at once; 0 means 1
+	done  chan struct{} // closed once the tick has been processed, if not nil
+}
+
+// lateTick tells a process to run its LateTick() once every process has
+// finished ticking.
+type lateTick struct {
+	done chan struct{} // closed once the late tick has been processed
+}
 
 type quit struct{}
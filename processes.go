@@ -130,3 +130,58 @@ type (
 	ResumeAnimation struct{}
 	ResetAnimation  struct{}
 )
+
+/* -- MapGeneratorProcess -- */
+
+// MapGeneratorProcess runs a potentially slow generator, such as one of
+// those in the procgen package, in the background so that the game loop
+// isn't held up.
+type MapGeneratorProcess struct {
+	done   chan interface{}
+	result interface{}
+
+	// Generate is the function that creates the map. It's run in its
+	// own goroutine.
+	Generate func() interface{}
+
+	// OnComplete is called with the result of Generate once it finishes.
+	OnComplete func(result interface{})
+
+	// Successor is the process to kick off after OnComplete is called.
+	Successor interface{}
+}
+
+func (p *MapGeneratorProcess) init() error {
+	if p.Generate == nil {
+		return errors.New("no generator was provided for this process")
+	}
+	p.done = make(chan interface{}, 1)
+	go func() {
+		p.done <- p.Generate()
+	}()
+	return nil
+}
+
+func (p *MapGeneratorProcess) tick() (bool, error) {
+	select {
+	case result := <-p.done:
+		p.result = result
+		if p.OnComplete != nil {
+			p.OnComplete(result)
+		}
+		return false, nil
+	default:
+		return true, nil
+	}
+}
+
+// Result() returns whatever Generate returned, or nil if it hasn't finished yet.
+func (p *MapGeneratorProcess) Result() interface{} {
+	return p.result
+}
+
+// Next() returns a reference to the process to run once
+// the map has been generated.
+func (p *MapGeneratorProcess) Next() interface{} {
+	return p.Successor
+}
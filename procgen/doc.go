@@ -0,0 +1,6 @@
+// Package procgen provides support for procedural content generation.
+//
+// Generators can take a while to run, so they're usually kicked off
+// from an allegory.MapGeneratorProcess, which runs them in the background
+// and hands over the result once it's ready.
+package procgen
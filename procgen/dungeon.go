@@ -0,0 +1,208 @@
+package procgen
+
+import (
+	"encoding/json"
+)
+
+// Tile is a single cell in a dungeon map.
+type Tile uint8
+
+const (
+	Wall Tile = iota
+	Floor
+)
+
+type Rect struct {
+	X, Y, W, H int
+}
+
+// Center() returns the cell closest to the middle of the rectangle.
+func (r Rect) Center() (x, y int) {
+	return r.X + r.W/2, r.Y + r.H/2
+}
+
+type Room struct {
+	Rect
+}
+
+// DungeonMap is the output of a dungeon generator.
+type DungeonMap struct {
+	Width     int
+	Height    int
+	Rooms     []Room
+	Corridors []Rect
+
+	// Tiles is indexed as Tiles[y][x].
+	Tiles [][]Tile
+}
+
+// NewDungeonMap() creates a map of the given size that is solid wall.
+func NewDungeonMap(width, height int) *DungeonMap {
+	tiles := make([][]Tile, height)
+	for y := range tiles {
+		tiles[y] = make([]Tile, width)
+	}
+	return &DungeonMap{Width: width, Height: height, Tiles: tiles}
+}
+
+// Carve() sets every tile within r to Floor.
+func (m *DungeonMap) Carve(r Rect) {
+	for y := r.Y; y < r.Y+r.H; y++ {
+		for x := r.X; x < r.X+r.W; x++ {
+			if x >= 0 && x < m.Width && y >= 0 && y < m.Height {
+				m.Tiles[y][x] = Floor
+			}
+		}
+	}
+}
+
+// ToJSON() serializes the map.
+func (m *DungeonMap) ToJSON() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// DungeonMapFromJSON() deserializes a map created by ToJSON().
+func DungeonMapFromJSON(data []byte) (*DungeonMap, error) {
+	m := new(DungeonMap)
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+/* -- BSPDungeonGenerator -- */
+
+// BSPDungeonGenerator generates dungeons by recursively splitting the
+// map into smaller and smaller partitions, placing a room in each leaf,
+// and then connecting sibling partitions with corridors.
+type BSPDungeonGenerator struct{}
+
+type bspNode struct {
+	area        Rect
+	left, right *bspNode
+	room        *Room
+}
+
+// Generate() creates a new dungeon. Every room will be at least
+// minRoomSize tiles wide and tall.
+func (g *BSPDungeonGenerator) Generate(width, height, minRoomSize int, rng *RNG) *DungeonMap {
+	m := NewDungeonMap(width, height)
+	if minRoomSize < 1 {
+		minRoomSize = 1
+	}
+	root := &bspNode{area: Rect{0, 0, width, height}}
+	g.split(root, minRoomSize+2, rng)
+	g.place(m, root, minRoomSize, rng)
+	return m
+}
+
+// split() divides a node in two until its partitions would become
+// smaller than minLeaf.
+func (g *BSPDungeonGenerator) split(node *bspNode, minLeaf int, rng *RNG) {
+	a := node.area
+	canSplitH, canSplitV := a.H >= minLeaf*2, a.W >= minLeaf*2
+	if !canSplitH && !canSplitV {
+		return
+	}
+
+	horizontal := canSplitH
+	if canSplitH && canSplitV {
+		switch {
+		case a.W > a.H:
+			horizontal = false
+		case a.H > a.W:
+			horizontal = true
+		default:
+			horizontal = rng.Intn(2) == 0
+		}
+	}
+
+	if horizontal {
+		at := rng.Range(minLeaf, a.H-minLeaf)
+		node.left = &bspNode{area: Rect{a.X, a.Y, a.W, at}}
+		node.right = &bspNode{area: Rect{a.X, a.Y + at, a.W, a.H - at}}
+	} else {
+		at := rng.Range(minLeaf, a.W-minLeaf)
+		node.left = &bspNode{area: Rect{a.X, a.Y, at, a.H}}
+		node.right = &bspNode{area: Rect{a.X + at, a.Y, a.W - at, a.H}}
+	}
+
+	g.split(node.left, minLeaf, rng)
+	g.split(node.right, minLeaf, rng)
+}
+
+// place() carves out a room in each leaf, then joins each pair of
+// siblings with a corridor.
+func (g *BSPDungeonGenerator) place(m *DungeonMap, node *bspNode, minRoomSize int, rng *RNG) {
+	if node.left == nil {
+		a := node.area
+		w := rng.Range(minRoomSize, a.W-2)
+		h := rng.Range(minRoomSize, a.H-2)
+		if w > a.W-2 || h > a.H-2 {
+			// the map is too small for even one room
+			return
+		}
+		room := &Room{Rect{
+			X: a.X + 1 + rng.Intn(a.W-w-1),
+			Y: a.Y + 1 + rng.Intn(a.H-h-1),
+			W: w,
+			H: h,
+		}}
+		node.room = room
+		m.Rooms = append(m.Rooms, *room)
+		m.Carve(room.Rect)
+		return
+	}
+
+	g.place(m, node.left, minRoomSize, rng)
+	g.place(m, node.right, minRoomSize, rng)
+
+	a, b := node.left.anyRoom(rng), node.right.anyRoom(rng)
+	if a == nil || b == nil {
+		return
+	}
+	ax, ay := a.Center()
+	bx, by := b.Center()
+	var corridors []Rect
+	if rng.Intn(2) == 0 {
+		corridors = []Rect{hline(ax, bx, ay), vline(ay, by, bx)}
+	} else {
+		corridors = []Rect{vline(ay, by, ax), hline(ax, bx, by)}
+	}
+	for _, c := range corridors {
+		m.Corridors = append(m.Corridors, c)
+		m.Carve(c)
+	}
+}
+
+// anyRoom() picks a random room from the node's subtree.
+func (node *bspNode) anyRoom(rng *RNG) *Room {
+	if node.room != nil {
+		return node.room
+	}
+	if node.left == nil {
+		return nil
+	}
+	first, second := node.left, node.right
+	if rng.Intn(2) == 0 {
+		first, second = second, first
+	}
+	if room := first.anyRoom(rng); room != nil {
+		return room
+	}
+	return second.anyRoom(rng)
+}
+
+func hline(x1, x2, y int) Rect {
+	if x2 < x1 {
+		x1, x2 = x2, x1
+	}
+	return Rect{x1, y, x2 - x1 + 1, 1}
+}
+
+func vline(y1, y2, x int) Rect {
+	if y2 < y1 {
+		y1, y2 = y2, y1
+	}
+	return Rect{x, y1, 1, y2 - y1 + 1}
+}
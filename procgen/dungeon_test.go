@@ -0,0 +1,96 @@
+package procgen
+
+import (
+	"reflect"
+	"testing"
+)
+
+var dungeonTests = []struct {
+	width, height, minRoomSize int
+	seed                       int64
+}{
+	{40, 30, 4, 1},
+	{80, 50, 5, 2},
+	{64, 64, 3, 42},
+	{12, 12, 4, 7},
+}
+
+func TestBSPDungeonIsDeterministic(t *testing.T) {
+	for _, test := range dungeonTests {
+		var g BSPDungeonGenerator
+		a := g.Generate(test.width, test.height, test.minRoomSize, NewRNG(test.seed))
+		b := g.Generate(test.width, test.height, test.minRoomSize, NewRNG(test.seed))
+		if !reflect.DeepEqual(a, b) {
+			t.Errorf("%dx%d with seed %d: two maps from the same seed differ", test.width, test.height, test.seed)
+		}
+	}
+}
+
+func TestBSPRoomsFitTheirLeaves(t *testing.T) {
+	for _, test := range dungeonTests {
+		var (
+			g    BSPDungeonGenerator
+			rng  = NewRNG(test.seed)
+			m    = NewDungeonMap(test.width, test.height)
+			root = &bspNode{area: Rect{0, 0, test.width, test.height}}
+		)
+		g.split(root, test.minRoomSize+2, rng)
+		g.place(m, root, test.minRoomSize, rng)
+
+		rooms := 0
+		var walk func(node *bspNode)
+		walk = func(node *bspNode) {
+			if node.left != nil {
+				walk(node.left)
+				walk(node.right)
+				return
+			}
+			if node.room == nil {
+				return
+			}
+			rooms++
+			a, r := node.area, node.room.Rect
+			// rooms keep a wall between them and the edge of their leaf
+			if r.X < a.X+1 || r.Y < a.Y+1 || r.X+r.W > a.X+a.W-1 || r.Y+r.H > a.Y+a.H-1 {
+				t.Errorf("seed %d: room %+v isn't inside leaf %+v", test.seed, r, a)
+			}
+			if r.W < test.minRoomSize || r.H < test.minRoomSize {
+				t.Errorf("seed %d: room %+v is smaller than %d", test.seed, r, test.minRoomSize)
+			}
+		}
+		walk(root)
+		if rooms == 0 || rooms != len(m.Rooms) {
+			t.Errorf("seed %d: found %d rooms in leaves, but the map has %d", test.seed, rooms, len(m.Rooms))
+		}
+	}
+}
+
+func TestBSPRoomsAreCarved(t *testing.T) {
+	var g BSPDungeonGenerator
+	m := g.Generate(40, 30, 4, NewRNG(3))
+	for _, room := range m.Rooms {
+		for y := room.Y; y < room.Y+room.H; y++ {
+			for x := room.X; x < room.X+room.W; x++ {
+				if m.Tiles[y][x] != Floor {
+					t.Fatalf("room %+v isn't carved at (%d, %d)", room.Rect, x, y)
+				}
+			}
+		}
+	}
+}
+
+func TestDungeonMapJSONRoundTrip(t *testing.T) {
+	var g BSPDungeonGenerator
+	m := g.Generate(30, 20, 3, NewRNG(5))
+	data, err := m.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := DungeonMapFromJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m, back) {
+		t.Error("map changed after a JSON round trip")
+	}
+}
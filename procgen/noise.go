@@ -0,0 +1,186 @@
+package procgen
+
+import (
+	"math"
+)
+
+// NoiseGenerator produces smooth, continuous noise that is well-suited
+// for height maps. Its output is determined entirely by the RNG that
+// was used to create it.
+type NoiseGenerator struct {
+	perm []int
+}
+
+// NewNoiseGenerator() creates a new noise generator seeded by rng.
+func NewNoiseGenerator(rng *RNG) NoiseGenerator {
+	p := rng.Perm(256)
+	perm := make([]int, 512)
+	for i := range perm {
+		perm[i] = p[i&255]
+	}
+	return NoiseGenerator{perm}
+}
+
+// NoiseConfig describes how noise should be sampled by GenerateHeightMap().
+type NoiseConfig struct {
+	// Scale is the number of cells covered by one unit of noise.
+	// Larger values produce smoother terrain.
+	Scale float64
+
+	// Octaves is the number of layers of noise to sum together.
+	Octaves int
+
+	// Persistence is how much the amplitude shrinks with each octave.
+	Persistence float64
+
+	// Lacunarity is how much the frequency grows with each octave.
+	Lacunarity float64
+
+	// Simplex uses simplex noise instead of Perlin noise.
+	Simplex bool
+}
+
+// DefaultNoiseConfig() returns a reasonable starting point for terrain.
+func DefaultNoiseConfig() NoiseConfig {
+	return NoiseConfig{Scale: 32, Octaves: 4, Persistence: 0.5, Lacunarity: 2}
+}
+
+// GenerateHeightMap() samples noise across a grid, returning values in
+// [0, 1] indexed as heights[y][x].
+func GenerateHeightMap(width, height int, generator NoiseGenerator, cfg NoiseConfig) [][]float64 {
+	sample := generator.Perlin
+	if cfg.Simplex {
+		sample = func(x, y, scale float64, octaves int, persistence, lacunarity float64) float64 {
+			return generator.fractal(generator.Simplex, x, y, scale, octaves, persistence, lacunarity)
+		}
+	}
+	heights := make([][]float64, height)
+	for y := range heights {
+		heights[y] = make([]float64, width)
+		for x := range heights[y] {
+			n := sample(float64(x), float64(y), cfg.Scale, cfg.Octaves, cfg.Persistence, cfg.Lacunarity)
+			heights[y][x] = math.Max(0, math.Min(1, (n+1)/2))
+		}
+	}
+	return heights
+}
+
+// Perlin() returns fractal Perlin noise at (x, y) in roughly [-1, 1].
+func (g NoiseGenerator) Perlin(x, y, scale float64, octaves int, persistence, lacunarity float64) float64 {
+	return g.fractal(g.perlin, x, y, scale, octaves, persistence, lacunarity)
+}
+
+// fractal() sums several octaves of a noise function, normalizing the
+// result back into [-1, 1].
+func (g NoiseGenerator) fractal(noise func(x, y float64) float64, x, y, scale float64, octaves int, persistence, lacunarity float64) float64 {
+	if scale <= 0 {
+		scale = 1
+	}
+	if octaves < 1 {
+		octaves = 1
+	}
+	var (
+		total     float64
+		maxValue  float64
+		amplitude = 1.0
+		frequency = 1 / scale
+	)
+	for i := 0; i < octaves; i++ {
+		total += noise(x*frequency, y*frequency) * amplitude
+		maxValue += amplitude
+		amplitude *= persistence
+		frequency *= lacunarity
+	}
+	return total / maxValue
+}
+
+// perlin() returns a single octave of Perlin noise.
+func (g NoiseGenerator) perlin(x, y float64) float64 {
+	xf, yf := math.Floor(x), math.Floor(y)
+	xi, yi := int(xf)&255, int(yf)&255
+	x, y = x-xf, y-yf
+	u, v := fade(x), fade(y)
+
+	p := g.perm
+	aa, ab := p[p[xi]+yi], p[p[xi]+yi+1]
+	ba, bb := p[p[xi+1]+yi], p[p[xi+1]+yi+1]
+
+	return lerp(v,
+		lerp(u, grad(aa, x, y), grad(ba, x-1, y)),
+		lerp(u, grad(ab, x, y-1), grad(bb, x-1, y-1)))
+}
+
+var (
+	simplexF2 = 0.5 * (math.Sqrt(3) - 1)
+	simplexG2 = (3 - math.Sqrt(3)) / 6
+)
+
+// Simplex() returns a single octave of simplex noise at (x, y) in roughly [-1, 1].
+func (g NoiseGenerator) Simplex(x, y float64) float64 {
+	s := (x + y) * simplexF2
+	i, j := math.Floor(x+s), math.Floor(y+s)
+	t := (i + j) * simplexG2
+	x0, y0 := x-(i-t), y-(j-t)
+
+	// figure out which of the two triangles we're in
+	var i1, j1 int
+	if x0 > y0 {
+		i1, j1 = 1, 0
+	} else {
+		i1, j1 = 0, 1
+	}
+
+	x1, y1 := x0-float64(i1)+simplexG2, y0-float64(j1)+simplexG2
+	x2, y2 := x0-1+2*simplexG2, y0-1+2*simplexG2
+
+	ii, jj := int(i)&255, int(j)&255
+	p := g.perm
+	corners := [3]struct {
+		hash int
+		x, y float64
+	}{
+		{p[ii+p[jj]], x0, y0},
+		{p[ii+i1+p[jj+j1]], x1, y1},
+		{p[ii+1+p[jj+1]], x2, y2},
+	}
+
+	var n float64
+	for _, c := range corners {
+		if t := 0.5 - c.x*c.x - c.y*c.y; t > 0 {
+			t *= t
+			n += t * t * grad(c.hash, c.x, c.y)
+		}
+	}
+	return 70 * n
+}
+
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
+
+// grad() computes the dot product of (x, y) with one of eight
+// gradient directions chosen by hash.
+func grad(hash int, x, y float64) float64 {
+	switch hash & 7 {
+	case 0:
+		return x + y
+	case 1:
+		return -x + y
+	case 2:
+		return x - y
+	case 3:
+		return -x - y
+	case 4:
+		return x
+	case 5:
+		return -x
+	case 6:
+		return y
+	default:
+		return -y
+	}
+}
@@ -0,0 +1,44 @@
+package procgen
+
+import (
+	"math/rand"
+)
+
+// RNG is a seeded random number generator. Generators that take an RNG
+// will always produce the same output for the same seed.
+type RNG struct {
+	r *rand.Rand
+}
+
+// NewRNG() creates a new random number generator from a seed.
+func NewRNG(seed int64) *RNG {
+	return &RNG{rand.New(rand.NewSource(seed))}
+}
+
+// Intn() returns a random number in [0, n).
+func (rng *RNG) Intn(n int) int {
+	return rng.r.Intn(n)
+}
+
+// Range() returns a random number in [min, max].
+func (rng *RNG) Range(min, max int) int {
+	if max <= min {
+		return min
+	}
+	return min + rng.r.Intn(max-min+1)
+}
+
+// Float64() returns a random number in [0.0, 1.0).
+func (rng *RNG) Float64() float64 {
+	return rng.r.Float64()
+}
+
+// Int63() returns a random non-negative 63-bit integer.
+func (rng *RNG) Int63() int64 {
+	return rng.r.Int63()
+}
+
+// Perm() returns a random permutation of the numbers [0, n).
+func (rng *RNG) Perm(n int) []int {
+	return rng.r.Perm(n)
+}
@@ -0,0 +1,248 @@
+package procgen
+
+import (
+	"fmt"
+	"sort"
+)
+
+// TilePos is a cell position within a generated grid.
+type TilePos struct {
+	X, Y int
+}
+
+// Contradiction is returned by WFCGrid.Collapse() when a cell is left
+// with no valid tiles.
+type Contradiction struct {
+	Pos TilePos
+}
+
+func (e *Contradiction) Error() string {
+	return fmt.Sprintf("wave function collapse reached a contradiction at (%d, %d)", e.Pos.X, e.Pos.Y)
+}
+
+// WFCGrid generates grids of tiles using the wave function collapse
+// algorithm. Every cell starts out able to be any tile, and cells are
+// collapsed one at a time, with each choice restricting what its neighbors
+// can become. Tiles can be anything comparable, so the same code can
+// place terrain tiles or larger pieces of content.
+type WFCGrid[T comparable] struct {
+	tiles       []T
+	index       map[T]int
+	compatible  [][]bool
+	constraints map[TilePos]T
+}
+
+// SetTileRules() defines which tiles may sit next to each other. Adjacency
+// is symmetric, so if a may be next to b, then b may also be next to a.
+func (g *WFCGrid[T]) SetTileRules(adjacency map[T][]T) {
+	g.index = make(map[T]int)
+	g.tiles = g.tiles[:0]
+	add := func(t T) {
+		if _, ok := g.index[t]; !ok {
+			g.index[t] = len(g.tiles)
+			g.tiles = append(g.tiles, t)
+		}
+	}
+	for t, neighbors := range adjacency {
+		add(t)
+		for _, n := range neighbors {
+			add(n)
+		}
+	}
+
+	// map iteration order is random, so sort the tiles to make sure
+	// the same seed always produces the same grid
+	sort.SliceStable(g.tiles, func(i, j int) bool {
+		return fmt.Sprint(g.tiles[i]) < fmt.Sprint(g.tiles[j])
+	})
+	for i, t := range g.tiles {
+		g.index[t] = i
+	}
+
+	g.compatible = make([][]bool, len(g.tiles))
+	for i := range g.compatible {
+		g.compatible[i] = make([]bool, len(g.tiles))
+	}
+	for t, neighbors := range adjacency {
+		for _, n := range neighbors {
+			a, b := g.index[t], g.index[n]
+			g.compatible[a][b] = true
+			g.compatible[b][a] = true
+		}
+	}
+}
+
+// SetInitialConstraints() fixes certain cells to a specific tile before
+// the grid is collapsed.
+func (g *WFCGrid[T]) SetInitialConstraints(constraints map[TilePos]T) {
+	g.constraints = constraints
+}
+
+// Collapse() generates a new grid, indexed as grid[y][x]. If the rules
+// paint the algorithm into a corner, an error of type Contradiction
+// is returned.
+func (g *WFCGrid[T]) Collapse(width, height int, rng *RNG) ([][]T, error) {
+	if len(g.tiles) == 0 {
+		return nil, fmt.Errorf("no tile rules have been set")
+	}
+
+	n := len(g.tiles)
+	cells := make([][]bool, width*height)
+	counts := make([]int, width*height)
+	for i := range cells {
+		cells[i] = make([]bool, n)
+		for t := range cells[i] {
+			cells[i][t] = true
+		}
+		counts[i] = n
+	}
+
+	w := &wfcWave{width: width, height: height, cells: cells, counts: counts, compatible: g.compatible}
+
+	// rule out any tiles that can't sit next to anything in the first place;
+	// this has to happen even with a single tile, since then every cell
+	// starts out collapsed and would otherwise never be checked
+	for i := range cells {
+		if err := w.propagate(i); err != nil {
+			return nil, err
+		}
+	}
+
+	// sort the constraints too, for the same reason as in SetTileRules()
+	fixed := make([]TilePos, 0, len(g.constraints))
+	for pos := range g.constraints {
+		fixed = append(fixed, pos)
+	}
+	sort.Slice(fixed, func(i, j int) bool {
+		return fixed[i].Y < fixed[j].Y || (fixed[i].Y == fixed[j].Y && fixed[i].X < fixed[j].X)
+	})
+	for _, pos := range fixed {
+		if pos.X < 0 || pos.X >= width || pos.Y < 0 || pos.Y >= height {
+			return nil, fmt.Errorf("constraint at (%d, %d) is outside the grid", pos.X, pos.Y)
+		}
+		t, ok := g.index[g.constraints[pos]]
+		if !ok {
+			return nil, fmt.Errorf("constraint at (%d, %d) uses a tile with no rules", pos.X, pos.Y)
+		}
+		i := pos.Y*width + pos.X
+		if !cells[i][t] {
+			return nil, &Contradiction{pos}
+		}
+		w.collapse(i, t)
+		if err := w.propagate(i); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		i := w.lowestEntropy(rng)
+		if i == -1 {
+			break
+		}
+		options := make([]int, 0, counts[i])
+		for t, ok := range cells[i] {
+			if ok {
+				options = append(options, t)
+			}
+		}
+		w.collapse(i, options[rng.Intn(len(options))])
+		if err := w.propagate(i); err != nil {
+			return nil, err
+		}
+	}
+
+	grid := make([][]T, height)
+	for y := range grid {
+		grid[y] = make([]T, width)
+		for x := range grid[y] {
+			for t, ok := range cells[y*width+x] {
+				if ok {
+					grid[y][x] = g.tiles[t]
+					break
+				}
+			}
+		}
+	}
+	return grid, nil
+}
+
+// wfcWave holds the in-progress state of a single collapse.
+type wfcWave struct {
+	width, height int
+	cells         [][]bool
+	counts        []int
+	compatible    [][]bool
+}
+
+// collapse() restricts cell i to the single tile t.
+func (w *wfcWave) collapse(i, t int) {
+	for j := range w.cells[i] {
+		w.cells[i][j] = j == t
+	}
+	w.counts[i] = 1
+}
+
+// lowestEntropy() finds the uncollapsed cell with the fewest options
+// left, breaking ties randomly. It returns -1 when every cell is collapsed.
+func (w *wfcWave) lowestEntropy(rng *RNG) int {
+	best, lowest := make([]int, 0), len(w.compatible)+1
+	for i, c := range w.counts {
+		if c <= 1 {
+			continue
+		}
+		if c < lowest {
+			best, lowest = best[:0], c
+		}
+		if c == lowest {
+			best = append(best, i)
+		}
+	}
+	if len(best) == 0 {
+		return -1
+	}
+	return best[rng.Intn(len(best))]
+}
+
+// propagate() removes options from the neighbors of cell start, and their
+// neighbors, and so on, until nothing changes.
+func (w *wfcWave) propagate(start int) error {
+	stack := []int{start}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		x, y := i%w.width, i/w.width
+
+		allowed := make([]bool, len(w.compatible))
+		for t, ok := range w.cells[i] {
+			if !ok {
+				continue
+			}
+			for u, c := range w.compatible[t] {
+				allowed[u] = allowed[u] || c
+			}
+		}
+
+		for _, d := range [4][2]int{{0, -1}, {1, 0}, {0, 1}, {-1, 0}} {
+			nx, ny := x+d[0], y+d[1]
+			if nx < 0 || nx >= w.width || ny < 0 || ny >= w.height {
+				continue
+			}
+			j := ny*w.width + nx
+			changed := false
+			for u, ok := range w.cells[j] {
+				if ok && !allowed[u] {
+					w.cells[j][u] = false
+					w.counts[j]--
+					changed = true
+				}
+			}
+			if w.counts[j] == 0 {
+				return &Contradiction{TilePos{nx, ny}}
+			}
+			if changed {
+				stack = append(stack, j)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package procgen
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var terrainRules = map[string][]string{
+	"water": {"water", "sand"},
+	"sand":  {"sand", "grass"},
+	"grass": {"grass"},
+}
+
+func TestWFCFollowsRules(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		var g WFCGrid[string]
+		g.SetTileRules(terrainRules)
+		g.SetInitialConstraints(map[TilePos]string{{0, 0}: "water", {9, 9}: "grass"})
+		grid, err := g.Collapse(10, 10, NewRNG(seed))
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if grid[0][0] != "water" || grid[9][9] != "grass" {
+			t.Errorf("seed %d: constraints weren't kept: %s, %s", seed, grid[0][0], grid[9][9])
+		}
+		for y := range grid {
+			for x := range grid[y] {
+				if x+1 < len(grid[y]) && !allowed(grid[y][x], grid[y][x+1]) {
+					t.Errorf("seed %d: %s next to %s at (%d, %d)", seed, grid[y][x], grid[y][x+1], x, y)
+				}
+				if y+1 < len(grid) && !allowed(grid[y][x], grid[y+1][x]) {
+					t.Errorf("seed %d: %s above %s at (%d, %d)", seed, grid[y][x], grid[y+1][x], x, y)
+				}
+			}
+		}
+	}
+}
+
+func TestWFCIsDeterministic(t *testing.T) {
+	collapse := func() [][]string {
+		var g WFCGrid[string]
+		g.SetTileRules(terrainRules)
+		grid, err := g.Collapse(8, 8, NewRNG(99))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return grid
+	}
+	if !reflect.DeepEqual(collapse(), collapse()) {
+		t.Error("two grids from the same seed differ")
+	}
+}
+
+func TestWFCErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		constraints   map[TilePos]string
+		contradiction bool
+	}{
+		{"incompatible neighbors", map[TilePos]string{{0, 0}: "water", {1, 0}: "grass"}, true},
+		{"outside the grid", map[TilePos]string{{5, 0}: "water"}, false},
+		{"unknown tile", map[TilePos]string{{0, 0}: "lava"}, false},
+	}
+	for _, test := range tests {
+		var g WFCGrid[string]
+		g.SetTileRules(terrainRules)
+		g.SetInitialConstraints(test.constraints)
+		_, err := g.Collapse(3, 3, NewRNG(1))
+		if err == nil {
+			t.Errorf("%s: expected an error", test.name)
+			continue
+		}
+		var c *Contradiction
+		if errors.As(err, &c) != test.contradiction {
+			t.Errorf("%s: got %v, contradiction expected: %v", test.name, err, test.contradiction)
+		}
+	}
+
+	var g WFCGrid[string]
+	if _, err := g.Collapse(3, 3, NewRNG(1)); err == nil {
+		t.Error("expected an error with no tile rules")
+	}
+
+	g.SetTileRules(map[string][]string{"rock": nil})
+	var c *Contradiction
+	if _, err := g.Collapse(2, 1, NewRNG(1)); !errors.As(err, &c) {
+		t.Errorf("a single tile that can't be next to itself: got %v, want a contradiction", err)
+	}
+	if _, err := g.Collapse(1, 1, NewRNG(1)); err != nil {
+		t.Errorf("a single cell has no neighbors to contradict: got %v", err)
+	}
+}
+
+func allowed(a, b string) bool {
+	for _, n := range terrainRules[a] {
+		if n == b {
+			return true
+		}
+	}
+	for _, n := range terrainRules[b] {
+		if n == a {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+package allegory
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dradtke/allegory/bus"
+	"io"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ExportFlameGraph() records what the engine does over the next frames
+// frames, then writes it to w as a flame graph in speedscope's JSON format
+// (see https://www.speedscope.app). Each frame, each process's Init(),
+// Tick(), HandleMessage() and Cleanup() calls, and each bus signal are
+// recorded. Since processes run in their own goroutines, every goroutine
+// gets its own profile.
+//
+// It blocks until all of the frames have been recorded, so it must not be
+// called from the game loop itself, e.g. from a state or actor.
+func ExportFlameGraph(frames int, w io.Writer) error {
+	if frames < 1 {
+		return errors.New("frames must be positive")
+	}
+	r := &flameRecorder{
+		frames: frames,
+		start:  time.Now(),
+		names:  make(map[string]int),
+		lanes:  make(map[int64]*flameLane),
+		done:   make(chan struct{}),
+	}
+	_profilerMutex.Lock()
+	if _profiler != nil {
+		_profilerMutex.Unlock()
+		return errors.New("a flame graph is already being recorded")
+	}
+	_profiler = r
+	_profilerMutex.Unlock()
+	bus.SetSignalHook(func(eventType bus.EventId) func() {
+		return profile(fmt.Sprintf("Signal(%d)", eventType))
+	})
+
+	<-r.done
+
+	bus.SetSignalHook(nil)
+	_profilerMutex.Lock()
+	_profiler = nil
+	_profilerMutex.Unlock()
+	return r.write(w)
+}
+
+// profile() records the start of something if a flame graph is being
+// recorded, returning the function to call when it ends.
+func profile(name string) (end func()) {
+	_profilerMutex.Lock()
+	r := _profiler
+	_profilerMutex.Unlock()
+	if r == nil {
+		return func() {}
+	}
+	return r.begin(name)
+}
+
+// profileProcess() is like profile(), but names the call after the
+// process's type and the method being called.
+func profileProcess(proc interface{}, method string) (end func()) {
+	_profilerMutex.Lock()
+	r := _profiler
+	_profilerMutex.Unlock()
+	if r == nil {
+		return func() {}
+	}
+	return r.begin(fmt.Sprintf("%T.%s", proc, method))
+}
+
+// profileFrame() marks the end of a frame.
+func profileFrame() {
+	_profilerMutex.Lock()
+	r := _profiler
+	_profilerMutex.Unlock()
+	if r != nil {
+		r.frame()
+	}
+}
+
+// flameRecorder collects the events for a flame graph.
+type flameRecorder struct {
+	mutex    sync.Mutex
+	frames   int // the number of frames left to record
+	start    time.Time
+	end      time.Duration
+	stopped  bool
+	names    map[string]int // index of each name in the shared frame list
+	nameList []string
+	lanes    map[int64]*flameLane // events recorded by each goroutine
+	done     chan struct{}
+}
+
+type flameLane struct {
+	events []flameEvent
+	open   []int // names that have been opened but not closed, innermost last
+}
+
+type flameEvent struct {
+	Type  string `json:"type"`
+	Frame int    `json:"frame"`
+	At    int64  `json:"at"`
+}
+
+func (r *flameRecorder) begin(name string) func() {
+	id := goroutineID()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.stopped {
+		return func() {}
+	}
+	frame, ok := r.names[name]
+	if !ok {
+		frame = len(r.nameList)
+		r.names[name] = frame
+		r.nameList = append(r.nameList, name)
+	}
+	lane, ok := r.lanes[id]
+	if !ok {
+		lane = new(flameLane)
+		r.lanes[id] = lane
+	}
+	lane.events = append(lane.events, flameEvent{"O", frame, int64(time.Since(r.start))})
+	lane.open = append(lane.open, frame)
+	return func() {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		if r.stopped {
+			// anything left open is closed off when the graph is written
+			return
+		}
+		lane.events = append(lane.events, flameEvent{"C", frame, int64(time.Since(r.start))})
+		lane.open = lane.open[:len(lane.open)-1]
+	}
+}
+
+func (r *flameRecorder) frame() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.stopped {
+		return
+	}
+	r.frames--
+	if r.frames == 0 {
+		r.stopped = true
+		r.end = time.Since(r.start)
+		close(r.done)
+	}
+}
+
+func (r *flameRecorder) write(w io.Writer) error {
+	type profile struct {
+		Type       string       `json:"type"`
+		Name       string       `json:"name"`
+		Unit       string       `json:"unit"`
+		StartValue int64        `json:"startValue"`
+		EndValue   int64        `json:"endValue"`
+		Events     []flameEvent `json:"events"`
+	}
+	type frame struct {
+		Name string `json:"name"`
+	}
+	var file struct {
+		Schema   string `json:"$schema"`
+		Exporter string `json:"exporter"`
+		Shared   struct {
+			Frames []frame `json:"frames"`
+		} `json:"shared"`
+		Profiles []profile `json:"profiles"`
+	}
+	file.Schema = "https://www.speedscope.app/file-format-schema.json"
+	file.Exporter = "allegory"
+	file.Shared.Frames = make([]frame, len(r.nameList))
+	for i, name := range r.nameList {
+		file.Shared.Frames[i] = frame{name}
+	}
+
+	ids := make([]int64, 0, len(r.lanes))
+	for id := range r.lanes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		lane := r.lanes[id]
+		events := lane.events
+		for i := len(lane.open) - 1; i >= 0; i-- {
+			events = append(events, flameEvent{"C", lane.open[i], int64(r.end)})
+		}
+		file.Profiles = append(file.Profiles, profile{
+			Type:     "evented",
+			Name:     fmt.Sprintf("goroutine %d", id),
+			Unit:     "nanoseconds",
+			EndValue: int64(r.end),
+			Events:   events,
+		})
+	}
+	return json.NewEncoder(w).Encode(file)
+}
+
+// goroutineID() returns the ID of the current goroutine, which Go doesn't
+// otherwise expose. It's slow, so it's only used while profiling.
+func goroutineID() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	// the trace starts with "goroutine <id> [running]:"
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseInt(string(b), 10, 64)
+	return id
+}
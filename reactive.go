@@ -0,0 +1,224 @@
+package allegory
+
+import (
+	"sync"
+)
+
+// Signal is a reactive value. Derived signals created with Map() and
+// Combine() recalculate themselves whenever the signals they depend on
+// change, and subscribers are notified of new values. The whole signal
+// graph is evaluated once per frame by the game loop, just before
+// rendering, so setting a value several times in a frame only
+// causes one update. Signals stay in the graph until they're closed
+// with Close(), so short-lived ones should always be closed.
+type Signal[T any] struct {
+	s *signal[T]
+}
+
+// Subscription is returned by Signal.Subscribe() and can be used to stop
+// receiving updates.
+type Subscription struct {
+	cancel func()
+}
+
+// Unsubscribe() stops the subscriber from being called again.
+func (s Subscription) Unsubscribe() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// reactive is the type-independent view of a signal used by the game loop.
+type reactive interface {
+	evaluate()
+	changed() bool
+	notify()
+}
+
+type signal[T any] struct {
+	mutex   sync.Mutex
+	value   T
+	pending bool // was Set() called since the last evaluation?
+	dirty   bool // did the value change during the last evaluation?
+	compute func() T
+	deps    []reactive
+	subs    map[int]func(T)
+	nextSub int
+	closed  bool // has Close() been called?
+}
+
+// NewSignal() creates a new signal holding an initial value.
+func NewSignal[T any](initial T) Signal[T] {
+	return newSignal(&signal[T]{value: initial})
+}
+
+func newSignal[T any](s *signal[T]) Signal[T] {
+	s.subs = make(map[int]func(T))
+	_signalsMutex.Lock()
+	_signals = append(_signals, s)
+	_signalsMutex.Unlock()
+	return Signal[T]{s}
+}
+
+// Value() returns the signal's current value.
+func (sig Signal[T]) Value() T {
+	sig.s.mutex.Lock()
+	defer sig.s.mutex.Unlock()
+	return sig.s.value
+}
+
+// Set() updates the signal's value. Derived signals and subscribers won't
+// see the change until the next frame. Set() has no effect on derived signals
+// or closed ones.
+func (sig Signal[T]) Set(value T) {
+	sig.s.mutex.Lock()
+	defer sig.s.mutex.Unlock()
+	if sig.s.compute != nil || sig.s.closed {
+		return
+	}
+	sig.s.value = value
+	sig.s.pending = true
+}
+
+// Map() returns a new signal whose value is always f applied to this one.
+func (sig Signal[T]) Map(f func(T) T) Signal[T] {
+	return newSignal(&signal[T]{
+		value:   f(sig.Value()),
+		compute: func() T { return f(sig.Value()) },
+		deps:    []reactive{sig.s},
+	})
+}
+
+// Combine() returns a new signal whose value is always f applied to this
+// one and other.
+func (sig Signal[T]) Combine(other Signal[T], f func(T, T) T) Signal[T] {
+	return newSignal(&signal[T]{
+		value:   f(sig.Value(), other.Value()),
+		compute: func() T { return f(sig.Value(), other.Value()) },
+		deps:    []reactive{sig.s, other.s},
+	})
+}
+
+// Subscribe() registers a function to be called with the new value
+// every time the signal changes.
+func (sig Signal[T]) Subscribe(f func(T)) Subscription {
+	s := sig.s
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return Subscription{}
+	}
+	id := s.nextSub
+	s.nextSub++
+	s.subs[id] = f
+	return Subscription{func() {
+		s.mutex.Lock()
+		delete(s.subs, id)
+		s.mutex.Unlock()
+	}}
+}
+
+// Close() removes the signal from the graph, so it's no longer evaluated
+// each frame and its subscribers are never called again. A derived signal
+// also lets go of the signals it was derived from. The signal keeps its
+// last value, and signals derived from it stop changing.
+func (sig Signal[T]) Close() {
+	s := sig.s
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return
+	}
+	s.closed, s.dirty, s.pending = true, false, false
+	s.compute, s.deps, s.subs = nil, nil, nil
+	s.mutex.Unlock()
+
+	_signalsMutex.Lock()
+	defer _signalsMutex.Unlock()
+	for i, other := range _signals {
+		if other == reactive(s) {
+			copy(_signals[i:], _signals[i+1:])
+			_signals[len(_signals)-1] = nil
+			_signals = _signals[:len(_signals)-1]
+			break
+		}
+	}
+}
+
+func (s *signal[T]) evaluate() {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return
+	}
+	if s.compute == nil {
+		s.dirty, s.pending = s.pending, false
+		s.mutex.Unlock()
+		return
+	}
+	compute, deps := s.compute, s.deps
+	s.mutex.Unlock()
+
+	dirty := false
+	for _, dep := range deps {
+		if dep.changed() {
+			dirty = true
+			break
+		}
+	}
+	var value T
+	if dirty {
+		value = compute()
+	}
+
+	s.mutex.Lock()
+	if !s.closed {
+		s.dirty = dirty
+		if dirty {
+			s.value = value
+		}
+	}
+	s.mutex.Unlock()
+}
+
+func (s *signal[T]) changed() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.dirty
+}
+
+func (s *signal[T]) notify() {
+	s.mutex.Lock()
+	if !s.dirty {
+		s.mutex.Unlock()
+		return
+	}
+	value := s.value
+	subs := make([]func(T), 0, len(s.subs))
+	for _, f := range s.subs {
+		subs = append(subs, f)
+	}
+	s.mutex.Unlock()
+
+	for _, f := range subs {
+		f(value)
+	}
+}
+
+// evaluateSignals() brings every signal up to date, then notifies
+// subscribers of anything that changed. Signals are evaluated in the
+// order they were created, which guarantees that a derived signal's
+// dependencies are always evaluated before it is.
+func evaluateSignals() {
+	_signalsMutex.Lock()
+	signals := make([]reactive, len(_signals))
+	copy(signals, _signals)
+	_signalsMutex.Unlock()
+
+	for _, s := range signals {
+		s.evaluate()
+	}
+	for _, s := range signals {
+		s.notify()
+	}
+}
@@ -0,0 +1,156 @@
+package allegory
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// replayFile is the structure that recordings are saved in.
+type replayFile struct {
+	Seed   int64
+	Inputs []replayInput
+	States map[int][]byte
+}
+
+type replayInput struct {
+	Frame int
+	Input PlayerInput
+}
+
+/* -- Recording -- */
+
+// RecordingSession records every input, and optionally state snapshots,
+// over the course of a game so that it can be replayed exactly later
+// with a PlaybackSession. This only works if the game is deterministic,
+// i.e. all randomness comes from an RNG seeded with the value passed to
+// Start() and the simulation depends only on its inputs.
+type RecordingSession struct {
+	mutex     sync.Mutex
+	recording bool
+	data      replayFile
+}
+
+// Start() begins a new recording, discarding anything previously recorded.
+func (r *RecordingSession) Start(seed int64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.recording = true
+	r.data = replayFile{Seed: seed, States: make(map[int][]byte)}
+}
+
+// Stop() stops recording. Anything recorded so far can still be saved.
+func (r *RecordingSession) Stop() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.recording = false
+}
+
+// RecordInput() records an input applied during frame. Inputs must be
+// recorded in the order they were applied.
+func (r *RecordingSession) RecordInput(frame int, input PlayerInput) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if !r.recording {
+		return
+	}
+	r.data.Inputs = append(r.data.Inputs, replayInput{frame, input})
+}
+
+// RecordState() records a snapshot of the game state at frame, which will
+// be checked against the replayed state by PlaybackSession.ValidateState().
+func (r *RecordingSession) RecordState(frame int, snapshot []byte) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if !r.recording {
+		return
+	}
+	r.data.States[frame] = snapshot
+}
+
+// Save() writes the recording to path.
+func (r *RecordingSession) Save(path string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(r.data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+/* -- Playback -- */
+
+// PlaybackSession replays a recording made by a RecordingSession.
+type PlaybackSession struct {
+	mutex sync.Mutex
+	data  replayFile
+	next  int
+}
+
+// Load() reads a recording from path and rewinds to its beginning.
+func (p *PlaybackSession) Load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var data replayFile
+	if err := json.NewDecoder(f).Decode(&data); err != nil {
+		return err
+	}
+	if data.States == nil {
+		return errors.New("invalid recording: " + path)
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.data, p.next = data, 0
+	return nil
+}
+
+// Seed() returns the seed that the recording was started with, which
+// should be used to seed the game's RNG before playback begins.
+func (p *PlaybackSession) Seed() int64 {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.data.Seed
+}
+
+// NextInput() returns the next input recorded during frame, if there is
+// one. Calling it repeatedly returns each of that frame's inputs in order.
+// Any inputs left over from earlier frames are skipped.
+func (p *PlaybackSession) NextInput(frame int) (PlayerInput, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for p.next < len(p.data.Inputs) && p.data.Inputs[p.next].Frame < frame {
+		p.next++
+	}
+	if p.next == len(p.data.Inputs) || p.data.Inputs[p.next].Frame != frame {
+		return PlayerInput{}, false
+	}
+	input := p.data.Inputs[p.next].Input
+	p.next++
+	return input, true
+}
+
+// ValidateState() returns false if a state was recorded for frame and
+// snapshot doesn't match it, which means the replay has diverged.
+func (p *PlaybackSession) ValidateState(frame int, snapshot []byte) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	recorded, ok := p.data.States[frame]
+	return !ok || bytes.Equal(recorded, snapshot)
+}
+
+// Finished() returns true once every recorded input has been returned.
+func (p *PlaybackSession) Finished() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.next == len(p.data.Inputs)
+}
@@ -0,0 +1,112 @@
+package allegory
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// FieldSpec describes a field that a message is expected to have.
+type FieldSpec struct {
+	Name string
+	Type reflect.Type
+}
+
+// RegisterMessageSchema() declares the fields that messages of the same
+// type as msgType must have, e.g.
+//
+//    RegisterMessageSchema(&Collision{}, []FieldSpec{
+//        {"Other", reflect.TypeOf((*Entity)(nil)).Elem()},
+//        {"Force", reflect.TypeOf(float32(0))},
+//    })
+//
+// Messages of that type, or pointers to it, are then checked before being
+// sent by NotifyProcess() and friends, which return an error instead of
+// sending ones that don't match. A field matches if its type is the one
+// given, or if the spec's type is an interface that the field implements;
+// interface fields are checked against the value they hold.
+// Registering a schema again replaces the old one, and passing nil fields
+// removes it. Messages without a schema aren't checked at all.
+func RegisterMessageSchema(msgType interface{}, fields []FieldSpec) {
+	t := messageType(reflect.TypeOf(msgType))
+
+	_messageSchemasMutex.Lock()
+	defer _messageSchemasMutex.Unlock()
+	old, _ := _messageSchemas.Load().(map[reflect.Type][]FieldSpec)
+	schemas := make(map[reflect.Type][]FieldSpec, len(old)+1)
+	for k, v := range old {
+		schemas[k] = v
+	}
+	if fields == nil {
+		delete(schemas, t)
+	} else {
+		schemas[t] = append([]FieldSpec(nil), fields...)
+	}
+	_messageSchemas.Store(schemas)
+}
+
+// validateMessage() checks a message against its schema, if it has one.
+func validateMessage(msg interface{}) error {
+	schemas, _ := _messageSchemas.Load().(map[reflect.Type][]FieldSpec)
+	if len(schemas) == 0 || msg == nil {
+		return nil
+	}
+	t := messageType(reflect.TypeOf(msg))
+	fields, ok := schemas[t]
+	if !ok {
+		return nil
+	}
+
+	v := reflect.ValueOf(msg)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return fmt.Errorf("invalid %s message: nil pointer", t)
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		if len(fields) > 0 {
+			return fmt.Errorf("invalid %s message: not a struct", t)
+		}
+		return nil
+	}
+	for _, spec := range fields {
+		field, ok := t.FieldByName(spec.Name)
+		if !ok {
+			return fmt.Errorf("invalid %s message: missing field %s", t, spec.Name)
+		}
+		if typeMatches(field.Type, spec.Type) {
+			continue
+		}
+		if field.Type.Kind() == reflect.Interface {
+			// the field could still hold a value of the right type
+			value := v.FieldByIndex(field.Index)
+			if value.IsNil() {
+				return fmt.Errorf("invalid %s message: field %s is nil", t, spec.Name)
+			}
+			if actual := value.Elem().Type(); !typeMatches(actual, spec.Type) {
+				return fmt.Errorf("invalid %s message: field %s holds %s, not %s", t, spec.Name, actual, spec.Type)
+			}
+			continue
+		}
+		return fmt.Errorf("invalid %s message: field %s is %s, not %s", t, spec.Name, field.Type, spec.Type)
+	}
+	return nil
+}
+
+// typeMatches() returns true if a field of type actual satisfies a spec
+// requiring want.
+func typeMatches(actual, want reflect.Type) bool {
+	if want == nil || actual == want {
+		return true
+	}
+	return want.Kind() == reflect.Interface && actual.Implements(want)
+}
+
+// messageType() returns the type that schemas are registered under, which
+// is the same for a type and pointers to it.
+func messageType(t reflect.Type) reflect.Type {
+	if t != nil && t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,150 @@
+package allegory
+
+import (
+	"github.com/dradtke/allegory/bus"
+	"sync"
+	"time"
+)
+
+// EventSequencer watches the bus for a series of events that have to fire
+// in order, such as the inputs of a combo or a cheat code. Each step has
+// to happen within its timeout of the one before it; if it doesn't, or if
+// one of the sequence's other events fires out of turn, the sequence
+// starts over, though the most recent events still count if they match its
+// beginning: Up, Up, Up, Down completes Up, Up, Down. Once every step has
+// happened, OnComplete() is called,
+// Event is signalled if it's set, and the sequence starts over again.
+//
+//    konami := new(EventSequencer).
+//        Expect(UpPressed, 0).
+//        Expect(UpPressed, time.Second).
+//        Expect(DownPressed, time.Second)
+//    konami.OnComplete = func() { ... }
+type EventSequencer struct {
+	OnComplete func()      // called whenever the sequence completes
+	Event      bus.EventId // if non-zero, signalled whenever the sequence completes
+
+	mutex     sync.Mutex
+	steps     []sequenceStep
+	listening map[bus.EventId]bus.ListenerID
+	next      int       // the step that's expected next
+	last      time.Time // when the last step happened
+	stopped   bool
+}
+
+type sequenceStep struct {
+	event   bus.EventId
+	timeout time.Duration
+}
+
+// Expect() adds a step to the end of the sequence, which is satisfied by
+// eventType being signalled within timeout of the previous step. The first
+// step's timeout is ignored, and a timeout of 0 means no limit.
+func (s *EventSequencer) Expect(eventType bus.EventId, timeout time.Duration) *EventSequencer {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.steps = append(s.steps, sequenceStep{eventType, timeout})
+	if s.listening == nil {
+		s.listening = make(map[bus.EventId]bus.ListenerID)
+	}
+	if _, ok := s.listening[eventType]; !ok && !s.stopped {
+		s.listening[eventType] = bus.AddListener(eventType, func(...interface{}) { s.handle(eventType) })
+	}
+	return s
+}
+
+// Progress() returns the number of steps that have been completed so far.
+func (s *EventSequencer) Progress() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.timedOut(time.Now()) {
+		s.next = 0
+	}
+	return s.next
+}
+
+// Reset() starts the sequence over.
+func (s *EventSequencer) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.next = 0
+}
+
+// Stop() stops the sequencer from responding to events and removes its
+// listeners from the bus. It can't be started again.
+func (s *EventSequencer) Stop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stopped = true
+	for eventType, id := range s.listening {
+		bus.RemoveListenerByID(eventType, id)
+	}
+}
+
+func (s *EventSequencer) handle(eventType bus.EventId) {
+	s.mutex.Lock()
+	if s.stopped || len(s.steps) == 0 {
+		s.mutex.Unlock()
+		return
+	}
+	now := time.Now()
+	if s.timedOut(now) {
+		s.next = 0
+	}
+	s.next = s.advance(eventType)
+	if s.next == 0 {
+		s.mutex.Unlock()
+		return
+	}
+	s.last = now
+	complete := s.next == len(s.steps)
+	if complete {
+		s.next = 0
+	}
+	onComplete, event := s.OnComplete, s.Event
+	s.mutex.Unlock()
+
+	if complete {
+		if onComplete != nil {
+			onComplete()
+		}
+		if event != 0 {
+			bus.Signal(event)
+		}
+	}
+}
+
+// advance() returns how many steps have been completed once eventType is
+// added to the ones completed so far. On a mismatch, it falls back to the
+// longest run of recent events that matches the start of the sequence, the
+// same way KMP string matching does.
+func (s *EventSequencer) advance(eventType bus.EventId) int {
+	// the recent events are the events of steps[:s.next], plus eventType;
+	// try each of their suffixes, longest first
+	for k := s.next + 1; k > 0; k-- {
+		if s.steps[k-1].event != eventType {
+			continue
+		}
+		offset := s.next + 1 - k
+		match := true
+		for i := 0; i < k-1; i++ {
+			if s.steps[i].event != s.steps[offset+i].event {
+				match = false
+				break
+			}
+		}
+		if match {
+			return k
+		}
+	}
+	return 0
+}
+
+// timedOut() returns true if the step in progress has run out of time.
+func (s *EventSequencer) timedOut(now time.Time) bool {
+	if s.next == 0 {
+		return false
+	}
+	timeout := s.steps[s.next].timeout
+	return timeout > 0 && now.Sub(s.last) > timeout
+}
@@ -0,0 +1,229 @@
+package allegory
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dradtke/allegory/bus"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultUploadTimeout is how long the built-in sharing backends wait for an
+// upload to finish if their Timeout isn't set.
+const DefaultUploadTimeout = 30 * time.Second
+
+// SharingBackend is a service that screenshots can be uploaded to.
+type SharingBackend interface {
+	// Upload() uploads a PNG image and returns a URL where it can be viewed.
+	Upload(img io.Reader, caption string) (string, error)
+}
+
+// SetSharingBackend() sets the backend used by ShareScreenshot(). If copyURL
+// is true, the URL of each shared screenshot is also copied to the clipboard.
+func SetSharingBackend(backend SharingBackend, copyURL bool) {
+	_sharingBackend = backend
+	_sharingCopyURL = copyURL
+}
+
+// ShareScreenshot() takes a screenshot of the display and uploads it to the
+// sharing backend in the background. Once it's been uploaded, a
+// ScreenshotSharedEvent is signaled with its URL.
+//
+// Since the screenshot is read from the backbuffer, this needs to be called
+// from the main thread, i.e. from a state or actor rather than a process.
+func ShareScreenshot(caption string) error {
+	if _sharingBackend == nil {
+		return errors.New("no sharing backend has been set")
+	}
+	if _display == nil {
+		return errors.New("there is no display to take a screenshot of")
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, bitmapImage(_display.Backbuffer())); err != nil {
+		return err
+	}
+	RunProcess(&shareProcess{
+		backend: _sharingBackend,
+		img:     buf.Bytes(),
+		caption: caption,
+		copyURL: _sharingCopyURL,
+	})
+	return nil
+}
+
+/* -- shareProcess -- */
+
+// shareProcess uploads a screenshot in the background.
+type shareProcess struct {
+	backend SharingBackend
+	img     []byte
+	caption string
+	copyURL bool
+	done    chan error
+	url     string
+}
+
+func (p *shareProcess) init() error {
+	p.done = make(chan error, 1)
+	go func() {
+		url, err := p.backend.Upload(bytes.NewReader(p.img), p.caption)
+		p.url = url
+		p.done <- err
+	}()
+	return nil
+}
+
+func (p *shareProcess) tick() (bool, error) {
+	select {
+	case err := <-p.done:
+		if err != nil {
+			return false, err
+		}
+		if p.copyURL {
+			Clipboard{}.CopyAsync(p.url, nil)
+		}
+		bus.Signal(bus.ScreenshotSharedEvent, p.url)
+		return false, nil
+	default:
+		return true, nil
+	}
+}
+
+/* -- Backends -- */
+
+// ImgurBackend uploads screenshots anonymously to Imgur.
+type ImgurBackend struct {
+	// ClientID is the application's Imgur API client ID.
+	ClientID string
+
+	// Timeout is how long to wait for an upload before giving up.
+	Timeout time.Duration
+}
+
+// Upload() uploads an image to Imgur and returns its link.
+func (b *ImgurBackend) Upload(img io.Reader, caption string) (string, error) {
+	body, contentType, err := multipartImage(img, "image", "title", caption)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest("POST", "https://api.imgur.com/3/image", body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Client-ID "+b.ClientID)
+	resp, err := uploadClient(b.Timeout).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Success bool
+		Data    struct {
+			Link  string
+			Error string
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if !result.Success {
+		return "", fmt.Errorf("imgur upload failed: %s", result.Data.Error)
+	}
+	return result.Data.Link, nil
+}
+
+// CustomHTTPBackend uploads screenshots to an arbitrary HTTP endpoint as a
+// multipart form. The endpoint is expected to respond with the URL of the
+// uploaded image as the body.
+type CustomHTTPBackend struct {
+	// URL is the endpoint that the form is POSTed to.
+	URL string
+
+	// ImageField and CaptionField are the names of the form fields,
+	// "image" and "caption" by default.
+	ImageField, CaptionField string
+
+	// Header holds any additional headers to send, e.g. for authentication.
+	Header http.Header
+
+	// Timeout is how long to wait for an upload before giving up.
+	Timeout time.Duration
+}
+
+// Upload() posts an image to the endpoint and returns the URL it responds with.
+func (b *CustomHTTPBackend) Upload(img io.Reader, caption string) (string, error) {
+	imageField, captionField := b.ImageField, b.CaptionField
+	if imageField == "" {
+		imageField = "image"
+	}
+	if captionField == "" {
+		captionField = "caption"
+	}
+	body, contentType, err := multipartImage(img, imageField, captionField, caption)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest("POST", b.URL, body)
+	if err != nil {
+		return "", err
+	}
+	for key, values := range b.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := uploadClient(b.Timeout).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("upload failed: %s", resp.Status)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// uploadClient() returns an HTTP client that gives up after timeout, or
+// DefaultUploadTimeout if it isn't positive. http.DefaultClient never times
+// out, which would leave both the request and its process hanging forever.
+func uploadClient(timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = DefaultUploadTimeout
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+// multipartImage() builds a multipart form containing an image and a caption.
+func multipartImage(img io.Reader, imageField, captionField, caption string) (io.Reader, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	part, err := w.CreateFormFile(imageField, "screenshot.png")
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := io.Copy(part, img); err != nil {
+		return nil, "", err
+	}
+	if caption != "" {
+		if err := w.WriteField(captionField, caption); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return &buf, w.FormDataContentType(), nil
+}
@@ -0,0 +1,110 @@
+package allegory
+
+import (
+	"errors"
+	"github.com/dradtke/allegory/config"
+	"github.com/dradtke/go-allegro/allegro"
+	"github.com/dradtke/go-allegro/allegro/primitives"
+	"math"
+)
+
+// GradientSkyView is an actor that fills the display with a vertical
+// color gradient. It should be added on layer 0 so that every other
+// actor is drawn on top of it:
+//
+//	allegory.AddActor(0, sky, nil)
+type GradientSkyView struct {
+	top, bottom           allegro.Color
+	nightTop, nightBottom allegro.Color
+	cycle                 *DayNightCycle
+}
+
+// SetTopColor() sets the color at the top of the display. When synced
+// to a day/night cycle, this is the color used at noon.
+func (v *GradientSkyView) SetTopColor(c allegro.Color) {
+	v.top = c
+}
+
+// SetBottomColor() sets the color at the bottom of the display. When synced
+// to a day/night cycle, this is the color used at noon.
+func (v *GradientSkyView) SetBottomColor(c allegro.Color) {
+	v.bottom = c
+}
+
+// SetNightTopColor() sets the color at the top of the display at midnight.
+func (v *GradientSkyView) SetNightTopColor(c allegro.Color) {
+	v.nightTop = c
+}
+
+// SetNightBottomColor() sets the color at the bottom of the display at midnight.
+func (v *GradientSkyView) SetNightBottomColor(c allegro.Color) {
+	v.nightBottom = c
+}
+
+// SyncWithDayNightCycle() ties the sky's colors to the time of day, blending
+// between the day and night color pairs. Passing nil stops syncing.
+func (v *GradientSkyView) SyncWithDayNightCycle(cycle *DayNightCycle) {
+	v.cycle = cycle
+}
+
+func (v *GradientSkyView) Render(delta float32) {
+	top, bottom := v.top, v.bottom
+	if v.cycle != nil {
+		light := v.cycle.Daylight()
+		top = lerpColor(v.nightTop, v.top, light)
+		bottom = lerpColor(v.nightBottom, v.bottom, light)
+	}
+	dw, dh := config.DisplaySize()
+	for y := 0; y < dh; y++ {
+		c := lerpColor(top, bottom, float32(y)/float32(dh))
+		primitives.DrawLine(
+			primitives.Point{X: 0, Y: float32(y) + 0.5},
+			primitives.Point{X: float32(dw), Y: float32(y) + 0.5},
+			c, 1)
+	}
+}
+
+// lerpColor() linearly interpolates between two colors.
+func lerpColor(a, b allegro.Color, t float32) allegro.Color {
+	ar, ag, ab, aa := a.UnmapRGBAf()
+	br, bg, bb, ba := b.UnmapRGBAf()
+	return allegro.MapRGBAf(ar+(br-ar)*t, ag+(bg-ag)*t, ab+(bb-ab)*t, aa+(ba-aa)*t)
+}
+
+/* -- DayNightCycle -- */
+
+// DayNightCycle is a process that keeps track of the time of day.
+type DayNightCycle struct {
+	timer uint
+
+	// Length is the number of ticks in a full day.
+	Length uint
+
+	// Start is the time of day that the cycle begins at, from 0 (midnight)
+	// to 1 (the following midnight).
+	Start float32
+}
+
+func (c *DayNightCycle) init() error {
+	if c.Length == 0 {
+		return errors.New("day/night cycle must have a non-zero length")
+	}
+	c.timer = uint(c.Start*float32(c.Length)) % c.Length
+	return nil
+}
+
+func (c *DayNightCycle) tick() (bool, error) {
+	c.timer = (c.timer + 1) % c.Length
+	return true, nil
+}
+
+// TimeOfDay() returns the current time of day, from 0 (midnight) up to 1.
+// Noon is 0.5.
+func (c *DayNightCycle) TimeOfDay() float32 {
+	return float32(c.timer) / float32(c.Length)
+}
+
+// Daylight() returns how bright it is outside, from 0 at midnight to 1 at noon.
+func (c *DayNightCycle) Daylight() float32 {
+	return float32(1-math.Cos(2*math.Pi*float64(c.TimeOfDay()))) / 2
+}
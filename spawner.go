@@ -0,0 +1,146 @@
+package allegory
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// EntityID identifies a networked entity. IDs are assigned by the server
+// so that every client agrees on them.
+//
+// IDs with the high bit set are reserved for objects created locally
+// without an ID (see ObjectBuilder.WithID()), so servers must only assign
+// IDs below 1<<31.
+type EntityID uint32
+
+// localEntityIDs is the first ID reserved for local objects.
+const localEntityIDs EntityID = 1 << 31
+
+// IsLocal() returns true if the ID was generated locally rather than
+// assigned by the server.
+func (id EntityID) IsLocal() bool {
+	return id >= localEntityIDs
+}
+
+// newLocalEntityID() generates an ID for a local object.
+func newLocalEntityID() EntityID {
+	return localEntityIDs | EntityID(atomic.AddUint32(&_nextObjectID, 1))&^localEntityIDs
+}
+
+// RegisterEntityFactory() registers a function that creates the process
+// for entities of the given type, configured from the spawn properties.
+func RegisterEntityFactory(typeName string, factory func(props map[string]interface{}) interface{}) {
+	_entityFactoriesMutex.Lock()
+	defer _entityFactoriesMutex.Unlock()
+	if _entityFactories == nil {
+		_entityFactories = make(map[string]func(map[string]interface{}) interface{})
+	}
+	_entityFactories[typeName] = factory
+}
+
+// SpawnMessage tells an EntitySpawner to spawn an entity.
+type SpawnMessage struct {
+	Type  string
+	ID    EntityID
+	Props map[string]interface{}
+}
+
+// DespawnMessage tells an EntitySpawner to despawn an entity.
+type DespawnMessage struct {
+	ID EntityID
+}
+
+// EntitySpawner manages the lifecycle of entities whose creation and
+// destruction is controlled by the server. It's a process, so the network
+// code can forward spawn and despawn requests to it as SpawnMessage's and
+// DespawnMessage's with NotifyProcess(), or it can call Spawn() and
+// Despawn() directly. Any entities still alive when the spawner quits
+// are despawned along with it.
+type EntitySpawner struct {
+	mutex    sync.Mutex
+	entities map[EntityID]interface{}
+}
+
+// Spawn() creates an entity using the factory registered for entityType
+// and starts its process.
+func (s *EntitySpawner) Spawn(entityType string, id EntityID, props map[string]interface{}) error {
+	_entityFactoriesMutex.Lock()
+	factory, ok := _entityFactories[entityType]
+	_entityFactoriesMutex.Unlock()
+	if !ok {
+		return fmt.Errorf("no factory registered for entity type '%s'", entityType)
+	}
+	if id.IsLocal() {
+		return fmt.Errorf("entity %d is in the range reserved for local objects", id)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.entities == nil {
+		s.entities = make(map[EntityID]interface{})
+	}
+	if proc, ok := s.entities[id]; ok && isRunning(proc) {
+		return fmt.Errorf("entity %d has already been spawned", id)
+	}
+	proc := factory(props)
+	if proc == nil {
+		return fmt.Errorf("factory for entity type '%s' returned nil", entityType)
+	}
+	s.entities[id] = proc
+	// so NotifyTeam() can reach it once it's put on a team
+	Teams().setProcesses(id, proc)
+	RunProcess(proc)
+	return nil
+}
+
+// Despawn() closes an entity's process.
+func (s *EntitySpawner) Despawn(id EntityID) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	proc, ok := s.entities[id]
+	if !ok {
+		return fmt.Errorf("entity %d does not exist", id)
+	}
+	delete(s.entities, id)
+	Teams().setProcesses(id)
+	Close(proc)
+	return nil
+}
+
+// Entity() returns the process for a spawned entity, if it's still running.
+func (s *EntitySpawner) Entity(id EntityID) (interface{}, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	proc, ok := s.entities[id]
+	if !ok || !isRunning(proc) {
+		return nil, false
+	}
+	return proc, true
+}
+
+func (s *EntitySpawner) handleMessage(msg interface{}) error {
+	var err error
+	switch m := msg.(type) {
+	case *SpawnMessage:
+		err = s.Spawn(m.Type, m.ID, m.Props)
+	case *DespawnMessage:
+		err = s.Despawn(m.ID)
+	}
+	// a bad message from the server shouldn't kill the spawner
+	if err != nil {
+		Error(err)
+	}
+	return nil
+}
+
+// Cleanup() despawns every remaining entity.
+func (s *EntitySpawner) Cleanup() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for id, proc := range s.entities {
+		Close(proc)
+		delete(s.entities, id)
+		Teams().setProcesses(id)
+	}
+}
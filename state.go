@@ -82,7 +82,7 @@ func PopState() *gameState {
 // blocking the current goroutine, then changes the game state.
 func NewStateWait(stateId StateID) {
 	go func() {
-		for len(_processes) > 0 {
+		for CountProcesses() > 0 {
 			runtime.Gosched()
 		}
 		NewState(stateId)
@@ -93,12 +93,59 @@ func NewStateWait(stateId StateID) {
 // waits for them to finish, then changes the game state.
 func NewStateNow(stateId StateID) {
 	NotifyAllProcesses(&quit{})
-	for len(_processes) > 0 {
+	for CountProcesses() > 0 {
 		runtime.Gosched()
 	}
 	NewState(stateId)
 }
 
+// pendingState is a state change queued up with queueNewStateNow().
+type pendingState struct {
+	id       StateID
+	quitSent bool // have the current state's processes been told to quit?
+}
+
+// queueNewStateNow() works like NewStateNow(), but leaves the work to the
+// game loop instead of blocking, so it's safe to call from a process that
+// has to quit itself before the state can change.
+func queueNewStateNow(stateId StateID) {
+	_pendingStateMutex.Lock()
+	defer _pendingStateMutex.Unlock()
+	_pendingState = &pendingState{id: stateId}
+}
+
+// applyPendingState() moves a state change queued with queueNewStateNow()
+// along. It tells the current state's processes to quit, then changes the
+// state once they have, which may take more than one frame.
+func applyPendingState() {
+	_pendingStateMutex.Lock()
+	p := _pendingState
+	_pendingStateMutex.Unlock()
+	if p == nil {
+		return
+	}
+	if !p.quitSent {
+		NotifyAllProcesses(&quit{})
+		p.quitSent = true
+	}
+	if CountProcesses() > 0 {
+		return
+	}
+	_pendingStateMutex.Lock()
+	if _pendingState == p {
+		_pendingState = nil
+	}
+	_pendingStateMutex.Unlock()
+	NewState(p.id)
+}
+
+// CountProcesses() returns the number of processes running in the current state.
+func CountProcesses() int {
+	_processMutex.Lock()
+	defer _processMutex.Unlock()
+	return len(_processes[_state.Current()])
+}
+
 /* -- stateStack -- */
 
 type stateStack struct {
@@ -109,6 +156,15 @@ func (s *stateStack) Empty() bool {
 	return s.stack.Len() == 0
 }
 
+func (s *stateStack) Contains(state *gameState) bool {
+	for e := s.stack.Front(); e != nil; e = e.Next() {
+		if e.Value.(*gameState) == state {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *stateStack) Current() *gameState {
 	front := s.stack.Front()
 	if front == nil {
@@ -129,6 +185,7 @@ func (s *stateStack) Push(state *gameState) {
 		_processes[state] = make([]interface{}, 0)
 		_actors[state] = make([]interface{}, 0)
 		_actorLayers[state] = make(map[uint][]interface{})
+		adoptMigratedProcesses(state)
 		state.init()
 	}
 }
@@ -141,6 +198,7 @@ func (s *stateStack) Pop() *gameState {
 
 		if actors, ok := _actors[oldState]; ok {
 			for _, actor := range actors {
+				delete(_actorLastUpdate, actor)
 				if actor, ok := actor.(Cleanupable); ok {
 					actor.Cleanup()
 				}
@@ -0,0 +1,101 @@
+package allegory
+
+import (
+	"time"
+)
+
+// RestartPolicy controls how Supervise() restarts a process that fails.
+type RestartPolicy struct {
+	// MaxRetries is the number of times to restart the process before
+	// giving up. A negative number means no limit.
+	MaxRetries int
+
+	// Backoff returns how long to wait before the given restart attempt,
+	// counting from 1. If it's nil, the process is restarted immediately.
+	Backoff func(attempt int) time.Duration
+
+	// WarmStart skips Init() when restarting a process that implements
+	// WarmRestartable and says it can be warm started. Cleanup() is still
+	// called when it fails, so it can undo anything left half-done. A
+	// process that failed in Init() itself is always initialized again.
+	WarmStart bool
+}
+
+// WarmRestartable is an interface for processes that can sometimes be
+// restarted by Supervise() without being initialized again, e.g. because
+// Init() loads assets that are still loaded. CanWarmStart() is asked
+// after each failure.
+type WarmRestartable interface {
+	CanWarmStart() bool
+}
+
+// ExponentialBackoff() returns a backoff function for a RestartPolicy
+// that starts at base and doubles with each attempt, up to max.
+func ExponentialBackoff(base, max time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		return d
+	}
+}
+
+// Supervise() runs a process like RunProcess(), but restarts it according
+// to policy whenever it fails, i.e. whenever Init(), Tick() or
+// HandleMessage() returns an error. Restarting means calling Init() again
+// and starting a new goroutine for it, so the process should reset itself
+// in Init(), unless policy allows warm starts. Processes that finish
+// cleanly or are closed aren't restarted.
+func Supervise(proc interface{}, policy RestartPolicy, opts ...ProcessOption) {
+	var (
+		attempt int
+		run     func(warm bool)
+	)
+	onExit := func(err error, initialized bool) {
+		if err == nil {
+			return
+		}
+		if policy.MaxRetries >= 0 && attempt >= policy.MaxRetries {
+			Errorf("giving up on process %T after %d restarts: %s", proc, attempt, err)
+			return
+		}
+		attempt++
+		var delay time.Duration
+		if policy.Backoff != nil {
+			delay = policy.Backoff(attempt)
+		}
+		warm := false
+		if p, ok := proc.(WarmRestartable); ok && policy.WarmStart && initialized {
+			warm = p.CanWarmStart()
+		}
+		// restart from a new goroutine, since this one may belong to the
+		// process that just exited
+		go func() {
+			time.Sleep(delay)
+			run(warm)
+		}()
+	}
+	supervisedOpts := append(append([]ProcessOption{}, opts...), withExitHandler(onExit))
+	run = func(warm bool) {
+		RunProcess(proc, append(supervisedOpts, withWarmStart(warm))...)
+	}
+	run(false)
+}
+
+// withWarmStart() sets whether a process should skip Init().
+func withWarmStart(warm bool) ProcessOption {
+	return func(o *processOptions) {
+		o.warmStart = warm
+	}
+}
+
+// withExitHandler() sets a function to call once a process has finished.
+func withExitHandler(f func(error, bool)) ProcessOption {
+	return func(o *processOptions) {
+		o.onExit = f
+	}
+}
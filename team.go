@@ -0,0 +1,199 @@
+package allegory
+
+import (
+	"fmt"
+	"github.com/dradtke/go-allegro/allegro"
+	"sync"
+)
+
+// Team is a group of entities that are on the same side.
+type Team struct {
+	ID    int
+	Name  string
+	Color allegro.Color
+}
+
+// TeamManager keeps track of which team each entity is on. There's a
+// single global instance returned by Teams(), which outlives any one game
+// state so that team assignments persist across state changes.
+//
+// Entities are allies if they're on the same team or on teams that have
+// been allied with SetAllied(), and enemies if they're both on a team but
+// aren't allies. Entities without a team are neither.
+//
+// The manager also keeps track of each entity's processes, for
+// NotifyTeam(). They're recorded by AddToTeam(), which ObjectBuilder uses,
+// and for the global manager, by EntitySpawner.
+type TeamManager struct {
+	mutex    sync.Mutex
+	teams    map[int]*Team
+	members  map[EntityID]int
+	procs    map[EntityID][]interface{} // the processes of each entity
+	alliance map[[2]int]bool
+}
+
+// NewTeamManager() creates an empty team manager.
+func NewTeamManager() *TeamManager {
+	return &TeamManager{
+		teams:    make(map[int]*Team),
+		members:  make(map[EntityID]int),
+		procs:    make(map[EntityID][]interface{}),
+		alliance: make(map[[2]int]bool),
+	}
+}
+
+// Teams() returns the global team manager.
+func Teams() *TeamManager {
+	_teamsOnce.Do(func() {
+		_teams = NewTeamManager()
+	})
+	return _teams
+}
+
+// NotifyTeam() sends a message to every entity on a team using the global
+// team manager.
+func NotifyTeam(teamID int, msg interface{}) {
+	Teams().NotifyTeam(teamID, msg)
+}
+
+// RegisterTeam() adds a team, replacing any existing team with the same ID.
+func (m *TeamManager) RegisterTeam(id int, name string, color allegro.Color) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.teams[id] = &Team{ID: id, Name: name, Color: color}
+}
+
+// Team() returns a registered team.
+func (m *TeamManager) Team(id int) (Team, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if team, ok := m.teams[id]; ok {
+		return *team, true
+	}
+	return Team{}, false
+}
+
+// AssignTeam() puts an entity on a team, taking it off its old one.
+// Any processes already recorded for the entity are kept.
+func (m *TeamManager) AssignTeam(entityID EntityID, teamID int) error {
+	return m.AddToTeam(entityID, teamID)
+}
+
+// AddToTeam() puts an entity on a team like AssignTeam(), and records the
+// processes that NotifyTeam() should send the team's messages to. If none
+// are given, any already recorded are kept.
+func (m *TeamManager) AddToTeam(entityID EntityID, teamID int, procs ...interface{}) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.teams[teamID]; !ok {
+		return fmt.Errorf("team %d has not been registered", teamID)
+	}
+	m.members[entityID] = teamID
+	if len(procs) > 0 {
+		m.procs[entityID] = append([]interface{}(nil), procs...)
+	}
+	return nil
+}
+
+// UnassignTeam() takes an entity off its team, e.g. once it's been
+// despawned, and forgets its processes.
+func (m *TeamManager) UnassignTeam(entityID EntityID) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.members, entityID)
+	delete(m.procs, entityID)
+}
+
+// setProcesses() records an entity's processes, whether or not it's on a
+// team yet. Passing none forgets them.
+func (m *TeamManager) setProcesses(entityID EntityID, procs ...interface{}) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if len(procs) == 0 {
+		delete(m.procs, entityID)
+		return
+	}
+	m.procs[entityID] = append([]interface{}(nil), procs...)
+}
+
+// GetTeam() returns the ID of the team an entity is on.
+func (m *TeamManager) GetTeam(entityID EntityID) (int, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	teamID, ok := m.members[entityID]
+	return teamID, ok
+}
+
+// SetAllied() sets whether two teams are allies.
+func (m *TeamManager) SetAllied(teamA, teamB int, allied bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if allied {
+		m.alliance[teamPair(teamA, teamB)] = true
+	} else {
+		delete(m.alliance, teamPair(teamA, teamB))
+	}
+}
+
+// AreAllies() returns true if two entities are on the same or allied teams.
+func (m *TeamManager) AreAllies(a, b EntityID) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	teamA, okA := m.members[a]
+	teamB, okB := m.members[b]
+	return okA && okB && m.allied(teamA, teamB)
+}
+
+// AreEnemies() returns true if two entities are both on a team, but
+// aren't allies.
+func (m *TeamManager) AreEnemies(a, b EntityID) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	teamA, okA := m.members[a]
+	teamB, okB := m.members[b]
+	return okA && okB && !m.allied(teamA, teamB)
+}
+
+// Members() returns the IDs of every entity on a team.
+func (m *TeamManager) Members(teamID int) []EntityID {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var members []EntityID
+	for id, team := range m.members {
+		if team == teamID {
+			members = append(members, id)
+		}
+	}
+	return members
+}
+
+// NotifyTeam() sends a message to the running processes of every entity
+// on a team. Entities whose processes were never recorded are skipped.
+func (m *TeamManager) NotifyTeam(teamID int, msg interface{}) {
+	m.mutex.Lock()
+	var procs []interface{}
+	for id, team := range m.members {
+		if team == teamID {
+			procs = append(procs, m.procs[id]...)
+		}
+	}
+	m.mutex.Unlock()
+
+	for _, proc := range procs {
+		if isRunning(proc) {
+			NotifyProcess(proc, msg)
+		}
+	}
+}
+
+func (m *TeamManager) allied(teamA, teamB int) bool {
+	return teamA == teamB || m.alliance[teamPair(teamA, teamB)]
+}
+
+// teamPair() returns the key for a pair of teams, regardless of order.
+func teamPair(a, b int) [2]int {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
@@ -0,0 +1,61 @@
+package allegory
+
+import (
+	"fmt"
+	"github.com/dradtke/allegory/config"
+	"github.com/dradtke/go-allegro/allegro"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// RenderToPNG() renders a single frame of a state to an off-screen bitmap
+// and writes it to w as a PNG, which is useful for things like generating
+// map thumbnails on a server. The state is pushed and initialized, drawn
+// along with its actors and any extra ones provided, then popped again.
+//
+// Allegro must already be running, but no display is needed; if there
+// isn't one, the bitmap is created in memory.
+func RenderToPNG(state StateID, actors []interface{}, width, height int, w io.Writer) error {
+	if _, ok := _stateMap[state]; !ok {
+		return fmt.Errorf("allegory.RenderToPNG() called with invalid state id: %s", state)
+	}
+	if _state.stack == nil {
+		initializeState()
+	}
+
+	PushState(state)
+	defer PopState()
+
+	bmp := allegro.CreateBitmap(width, height)
+	if bmp == nil {
+		return fmt.Errorf("failed to create %dx%d bitmap", width, height)
+	}
+	defer bmp.Destroy()
+
+	bmp.AsTarget(func() {
+		allegro.ClearToColor(config.BlankColor())
+		render(0)
+		for _, actor := range actors {
+			if actor, ok := actor.(Renderable); ok {
+				actor.Render(0)
+			}
+		}
+	})
+
+	return png.Encode(w, bitmapImage(bmp))
+}
+
+// bitmapImage() copies a bitmap's pixels into an image.
+func bitmapImage(bmp *allegro.Bitmap) *image.RGBA {
+	width, height := bmp.Width(), bmp.Height()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, a := bmp.Pixel(x, y).UnmapRGBA()
+			img.SetRGBA(x, y, color.RGBA{r, g, b, a})
+		}
+	}
+	return img
+}
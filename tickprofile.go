@@ -0,0 +1,131 @@
+package allegory
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TickProfileSamples is the number of recent ticks that each process's
+// tick profile is based on.
+const TickProfileSamples = 128
+
+// TickProfile summarizes how long a process's recent ticks took.
+type TickProfile struct {
+	Samples int // the number of ticks measured, up to TickProfileSamples
+	Min     time.Duration
+	Max     time.Duration
+	Mean    time.Duration
+	P95     time.Duration
+}
+
+// tickHistory holds a process's most recent tick durations.
+type tickHistory struct {
+	mutex     sync.Mutex
+	durations [TickProfileSamples]time.Duration
+	next      int
+	count     int
+}
+
+// EnableTickProfiling() starts timing every process's ticks, so that
+// GetTickProfile() can be used to find the ones that are slowing the game
+// down.
+func EnableTickProfiling() {
+	atomic.StoreInt32(&_tickProfiling, 1)
+}
+
+// DisableTickProfiling() stops timing ticks and throws away the profiles
+// collected so far.
+func DisableTickProfiling() {
+	atomic.StoreInt32(&_tickProfiling, 0)
+	_tickProfilesMutex.Lock()
+	defer _tickProfilesMutex.Unlock()
+	_tickProfiles = nil
+}
+
+// SetTickBudget() sets how long a single tick is allowed to take. Any
+// tick that takes longer logs a warning naming the process, whether or not
+// tick profiling is enabled. A budget of 0, the default, disables the
+// warnings.
+func SetTickBudget(budget time.Duration) {
+	atomic.StoreInt64(&_tickBudget, int64(budget))
+}
+
+// GetTickProfile() returns the tick profile for a running process. It's
+// empty unless tick profiling is enabled and the process has ticked since.
+func GetTickProfile(proc interface{}) TickProfile {
+	_tickProfilesMutex.Lock()
+	h, ok := _tickProfiles[proc]
+	_tickProfilesMutex.Unlock()
+	if !ok {
+		return TickProfile{}
+	}
+
+	h.mutex.Lock()
+	samples := append([]time.Duration(nil), h.durations[:h.count]...)
+	h.mutex.Unlock()
+	if len(samples) == 0 {
+		return TickProfile{}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	var total time.Duration
+	for _, d := range samples {
+		total += d
+	}
+	return TickProfile{
+		Samples: len(samples),
+		Min:     samples[0],
+		Max:     samples[len(samples)-1],
+		Mean:    total / time.Duration(len(samples)),
+		P95:     samples[(len(samples)*95-1)/100],
+	}
+}
+
+// timeTicks() wraps a process's tick function so that it's timed, if tick
+// profiling or the tick budget is enabled.
+func timeTicks(proc interface{}, tickFn func() (bool, error)) func() (bool, error) {
+	if atomic.LoadInt32(&_tickProfiling) == 0 && atomic.LoadInt64(&_tickBudget) == 0 {
+		return tickFn
+	}
+	return func() (bool, error) {
+		start := time.Now()
+		alive, err := tickFn()
+		elapsed := time.Since(start)
+		if budget := time.Duration(atomic.LoadInt64(&_tickBudget)); budget > 0 && elapsed > budget {
+			Errorf("%s took %s to tick, over the budget of %s", processName(proc), elapsed, budget)
+		}
+		if atomic.LoadInt32(&_tickProfiling) == 1 {
+			recordTick(proc, elapsed)
+		}
+		return alive, err
+	}
+}
+
+func recordTick(proc interface{}, elapsed time.Duration) {
+	_tickProfilesMutex.Lock()
+	if _tickProfiles == nil {
+		_tickProfiles = make(map[interface{}]*tickHistory)
+	}
+	h, ok := _tickProfiles[proc]
+	if !ok {
+		h = new(tickHistory)
+		_tickProfiles[proc] = h
+	}
+	_tickProfilesMutex.Unlock()
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.durations[h.next] = elapsed
+	h.next = (h.next + 1) % len(h.durations)
+	if h.count < len(h.durations) {
+		h.count++
+	}
+}
+
+// forgetTickProfile() throws away a finished process's tick profile.
+func forgetTickProfile(proc interface{}) {
+	_tickProfilesMutex.Lock()
+	defer _tickProfilesMutex.Unlock()
+	delete(_tickProfiles, proc)
+}
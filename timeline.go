@@ -0,0 +1,189 @@
+package allegory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dradtke/allegory/bus"
+	"github.com/dradtke/allegory/config"
+	"io/ioutil"
+	"sort"
+)
+
+// TimeTimeline is a list of events tied to points in game time, for
+// scripting things like enemy waves or cutscenes. Game time only advances
+// when processes are ticked, so it stops while the game is paused.
+type TimeTimeline struct {
+	events []timedEvent
+}
+
+type timedEvent struct {
+	time      float32
+	eventType bus.EventId
+	params    []interface{}
+}
+
+// AddEvent() schedules an event to be signaled on the bus once the given
+// number of seconds of game time have passed since the timeline started.
+func (t *TimeTimeline) AddEvent(time float32, eventType bus.EventId, params ...interface{}) {
+	t.events = append(t.events, timedEvent{time, eventType, params})
+	sort.SliceStable(t.events, func(i, j int) bool {
+		return t.events[i].time < t.events[j].time
+	})
+}
+
+// Start() kicks off a TimeTimelineProcess for this timeline and returns it.
+func (t *TimeTimeline) Start() *TimeTimelineProcess {
+	p := &TimeTimelineProcess{Timeline: t}
+	RunProcess(p)
+	return p
+}
+
+/* -- TimeTimelineProcess -- */
+
+// TimeTimelineProcess plays back a TimeTimeline, finishing once every
+// event has been signaled.
+type TimeTimelineProcess struct {
+	ticks int
+	next  int // index of the next event to fire
+
+	Timeline *TimeTimeline
+
+	// Successor is the process to kick off after the last event fires.
+	Successor interface{}
+}
+
+func (p *TimeTimelineProcess) init() error {
+	if p.Timeline == nil {
+		return errors.New("no timeline was provided for this process")
+	}
+	p.ticks = 0
+	p.next = 0
+	return nil
+}
+
+func (p *TimeTimelineProcess) tick() (bool, error) {
+	p.ticks++
+	now := p.Elapsed()
+	events := p.Timeline.events
+	for ; p.next < len(events) && events[p.next].time <= now; p.next++ {
+		e := events[p.next]
+		bus.Signal(e.eventType, e.params...)
+	}
+	return p.next < len(events), nil
+}
+
+// Elapsed() returns the number of seconds of game time since the
+// timeline started.
+func (p *TimeTimelineProcess) Elapsed() float32 {
+	return float32(p.ticks) / float32(config.Fps())
+}
+
+// Next() returns a reference to the process to run once
+// the timeline has finished.
+func (p *TimeTimelineProcess) Next() interface{} {
+	return p.Successor
+}
+
+/* -- Loading -- */
+
+// timelineEntry is a single entry in a timeline file.
+type timelineEntry struct {
+	Time   *float32      `json:"time"`
+	Event  string        `json:"event"`
+	Params []interface{} `json:"params"`
+}
+
+// ValidationError describes a problem with one entry of a timeline file.
+type ValidationError struct {
+	Index   int // the index of the entry
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("timeline entry %d: %s", e.Index, e.Message)
+}
+
+// ValidateTimeline() checks that a timeline file is well-formed: it must be
+// a JSON array of objects, each with a non-negative "time" in seconds, an
+// "event" name, and optionally a "params" array. The returned error is
+// only non-nil if the data isn't valid JSON of that shape.
+func ValidateTimeline(data []byte) ([]ValidationError, error) {
+	var entries []timelineEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return validateTimelineEntries(entries, nil), nil
+}
+
+func validateTimelineEntries(entries []timelineEntry, known map[string]bus.EventId) []ValidationError {
+	var errs []ValidationError
+	for i, entry := range entries {
+		if entry.Time == nil {
+			errs = append(errs, ValidationError{i, "missing time"})
+		} else if *entry.Time < 0 {
+			errs = append(errs, ValidationError{i, fmt.Sprintf("negative time %g", *entry.Time)})
+		}
+		if entry.Event == "" {
+			errs = append(errs, ValidationError{i, "missing event"})
+		} else if _, ok := known[entry.Event]; known != nil && !ok {
+			errs = append(errs, ValidationError{i, fmt.Sprintf("unknown event '%s'", entry.Event)})
+		}
+	}
+	return errs
+}
+
+// TimelineLoader creates timelines from files written by level designers,
+// as described by ValidateTimeline(). Event names are mapped to event
+// types with RegisterEvent(). Parameters are passed along as they were
+// decoded from JSON, so listeners for these events need to take numbers
+// as float64's.
+type TimelineLoader struct {
+	events map[string]bus.EventId
+}
+
+// NewTimelineLoader() creates a timeline loader with no events registered.
+func NewTimelineLoader() *TimelineLoader {
+	return &TimelineLoader{events: make(map[string]bus.EventId)}
+}
+
+// RegisterEvent() lets timeline files refer to an event type by name.
+func (l *TimelineLoader) RegisterEvent(name string, eventType bus.EventId) {
+	l.events[name] = eventType
+}
+
+// Validate() is like ValidateTimeline(), but also checks that every event
+// name has been registered.
+func (l *TimelineLoader) Validate(data []byte) ([]ValidationError, error) {
+	var entries []timelineEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return validateTimelineEntries(entries, l.events), nil
+}
+
+// Load() creates a timeline from the contents of a timeline file. If the
+// file has any validation errors, the first one is returned.
+func (l *TimelineLoader) Load(data []byte) (*TimeTimeline, error) {
+	var entries []timelineEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	if errs := validateTimelineEntries(entries, l.events); len(errs) > 0 {
+		return nil, errs[0]
+	}
+	t := new(TimeTimeline)
+	for _, entry := range entries {
+		t.AddEvent(*entry.Time, l.events[entry.Event], entry.Params...)
+	}
+	return t, nil
+}
+
+// LoadFile() creates a timeline from a timeline file on disk.
+func (l *TimelineLoader) LoadFile(path string) (*TimeTimeline, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return l.Load(data)
+}
@@ -0,0 +1,84 @@
+package allegory
+
+import (
+	"fmt"
+)
+
+// TransitionContext is shared between the steps of a scene transition.
+type TransitionContext struct {
+	From, To StateID
+
+	// Values can be used by steps to pass data along to later steps,
+	// or to the new state.
+	Values map[string]interface{}
+}
+
+// TransitionAware is an interface for processes that want access to the
+// context of the scene transition they're a step in. SetTransitionContext()
+// is called just before the step is started.
+type TransitionAware interface {
+	SetTransitionContext(ctx *TransitionContext)
+}
+
+// SceneTransition is a process that runs a series of steps, one after the
+// other, before switching to a new state as if by NewStateNow(). Each step
+// is a normal process, and the next step starts once the previous one
+// finishes. It's for logic rather than visuals, like saving the game
+// or unloading assets. The state change itself is made by the game loop.
+type SceneTransition struct {
+	steps   []interface{}
+	current int
+	ctx     *TransitionContext
+}
+
+// SceneTransitionProcess() creates and starts a scene transition from one
+// state to another. The from state must be the current state.
+func SceneTransitionProcess(from, to StateID, steps ...interface{}) *SceneTransition {
+	t := &SceneTransition{
+		steps:   steps,
+		current: -1,
+		ctx:     &TransitionContext{From: from, To: to, Values: make(map[string]interface{})},
+	}
+	RunProcess(t)
+	return t
+}
+
+// Context() returns the context shared by each of the transition's steps.
+func (t *SceneTransition) Context() *TransitionContext {
+	return t.ctx
+}
+
+func (t *SceneTransition) init() error {
+	from, ok := _stateMap[t.ctx.From]
+	if !ok {
+		return fmt.Errorf("scene transition from invalid state '%s'", t.ctx.From)
+	}
+	if _, ok := _stateMap[t.ctx.To]; !ok {
+		return fmt.Errorf("scene transition to invalid state '%s'", t.ctx.To)
+	}
+	if _state.Current() != from {
+		return fmt.Errorf("scene transition from '%s', which isn't the current state", t.ctx.From)
+	}
+	return nil
+}
+
+func (t *SceneTransition) tick() (bool, error) {
+	if t.current >= 0 && t.current < len(t.steps) && isRunning(t.steps[t.current]) {
+		return true, nil
+	}
+	t.current++
+	if t.current < len(t.steps) {
+		step := t.steps[t.current]
+		if step, ok := step.(TransitionAware); ok {
+			step.SetTransitionContext(t.ctx)
+		}
+		RunProcess(step)
+		return true, nil
+	}
+
+	// NewStateNow() waits for every process to quit, including this one,
+	// so leave it to the game loop, which also keeps the state change on
+	// the main thread
+	queueNewStateNow(t.ctx.To)
+	return false, nil
+}
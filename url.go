@@ -0,0 +1,54 @@
+package allegory
+
+import (
+	"net/url"
+	"os"
+	"strings"
+)
+
+// RegisterURLHandler() registers a handler for URLs with the given scheme,
+// e.g. "mygame" for "mygame://level/5". Handlers typically call NewState()
+// to jump straight to whatever the URL points at.
+//
+// Only URLs passed as command-line arguments are picked up automatically:
+// any arguments that match a registered scheme are handled as soon as the
+// initial state has been pushed. That covers Windows, where a protocol
+// registered under HKEY_CLASSES_ROOT is launched with the URL as "%1", and
+// Linux desktop entries that pass it as %u. Registering the scheme itself
+// with the operating system is left to the installer.
+//
+// macOS doesn't pass URLs on the command line; it delivers them as an
+// Apple Event (kAEGetURL) to the running application, and Android delivers
+// them as intents. Neither is hooked up by the engine, so on those
+// platforms the game has to receive the URL itself and pass it to
+// HandleURL().
+func RegisterURLHandler(scheme string, handler func(url *url.URL)) {
+	if _urlHandlers == nil {
+		_urlHandlers = make(map[string]func(*url.URL))
+	}
+	_urlHandlers[strings.ToLower(scheme)] = handler
+}
+
+// HandleURL() dispatches a URL to the handler registered for its scheme,
+// returning false if it couldn't be parsed or there was no handler. This
+// can be used to pass along URLs that arrive some other way, such as from
+// a second instance of the game.
+func HandleURL(rawurl string) bool {
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Scheme == "" {
+		return false
+	}
+	handler, ok := _urlHandlers[u.Scheme]
+	if !ok {
+		return false
+	}
+	handler(u)
+	return true
+}
+
+// handleLaunchURLs() handles any URLs the game was launched with.
+func handleLaunchURLs() {
+	for _, arg := range os.Args[1:] {
+		HandleURL(arg)
+	}
+}